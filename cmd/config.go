@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/konflux-ci/konflux-build-cli/cmd/config"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "A sub command group to work with the CLI configuration",
+}
+
+func init() {
+	configCmd.AddCommand(config.ShowCmd)
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/commands"
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+var ShowCmd = &cobra.Command{
+	Use:   "show [command]",
+	Short: "Shows effective parameter values and their sources",
+	Long: `Shows effective parameter values and their sources.
+
+For each parameter of the given command, e.g. "image apply-tags", or of all commands if none is given,
+prints the value the command would use and where it comes from:
+ - env: the parameter environment variable
+ - config: the configuration file (see --config parameter)
+ - default: the default value
+
+Values of sensitive parameters are masked.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		l.Logger.Debug("Starting config show")
+		configShow, err := commands.NewConfigShow(cmd, args)
+		if err != nil {
+			l.Logger.Fatal(err)
+		}
+		if err := configShow.Run(); err != nil {
+			l.Logger.Fatal(err)
+		}
+		l.Logger.Debug("Finished config show")
+	},
+}
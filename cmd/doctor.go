@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/commands"
+	"github.com/konflux-ci/konflux-build-cli/pkg/common"
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnoses the environment the CLI runs in",
+	Long: `Diagnoses the environment the CLI runs in.
+
+Reports:
+ - available external tools and their versions
+ - registry reachability and authentication status for the given images (see --image parameter)
+ - whether the given result paths are writable (see --result-path parameter)
+ - KBC_* environment variables in effect, with sensitive values masked
+ - proxy, CA certificates and registry auth settings
+
+Exits with non-zero code if any problem is found.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		l.Logger.Debug("Starting doctor")
+		doctor, err := commands.NewDoctor(cmd)
+		if err != nil {
+			l.Logger.Fatal(err)
+		}
+		if err := doctor.Run(); err != nil {
+			l.Logger.Fatal(err)
+		}
+		l.Logger.Debug("Finished doctor")
+	},
+}
+
+func init() {
+	common.RegisterParameters(doctorCmd, commands.DoctorParamsConfig)
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/konflux-ci/konflux-build-cli/cmd/generate"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "A sub command group to generate definitions from the CLI commands",
+}
+
+func init() {
+	generateCmd.AddCommand(generate.TektonCmd)
+	generateCmd.AddCommand(generate.DocsCmd)
+}
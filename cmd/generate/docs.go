@@ -0,0 +1,34 @@
+package generate
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/commands"
+	"github.com/konflux-ci/konflux-build-cli/pkg/common"
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+var DocsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generates reference documentation of all commands",
+	Long: `Generates reference documentation of all commands, one file per command, as Markdown or man pages.
+
+For each command, it lists the parameters with their flags, short names, environment variables, types,
+default values, whether they are required and accepted values, parameter groups, results and global flags.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		l.Logger.Debug("Starting generate docs")
+		generateDocs, err := commands.NewGenerateDocs(cmd)
+		if err != nil {
+			l.Logger.Fatal(err)
+		}
+		if err := generateDocs.Run(); err != nil {
+			l.Logger.Fatal(err)
+		}
+		l.Logger.Debug("Finished generate docs")
+	},
+}
+
+func init() {
+	common.RegisterParameters(DocsCmd, commands.GenerateDocsParamsConfig)
+}
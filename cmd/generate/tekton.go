@@ -0,0 +1,38 @@
+package generate
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/commands"
+	"github.com/konflux-ci/konflux-build-cli/pkg/common"
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+var TektonCmd = &cobra.Command{
+	Use:   "tekton",
+	Short: "Generates Tekton Task or StepAction running the given command",
+	Long: `Generates Tekton Task or StepAction running the given command, e.g. "image apply-tags".
+
+The definition is derived from the command parameters, so it stays in sync with the CLI:
+ - each parameter becomes a Tekton param with the same default value
+ - single value parameters are passed via their environment variables, arrays via arguments
+ - result-* parameters become Tekton results
+
+The YAML is printed to stdout.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		l.Logger.Debug("Starting generate tekton")
+		generateTekton, err := commands.NewGenerateTekton(cmd)
+		if err != nil {
+			l.Logger.Fatal(err)
+		}
+		if err := generateTekton.Run(); err != nil {
+			l.Logger.Fatal(err)
+		}
+		l.Logger.Debug("Finished generate tekton")
+	},
+}
+
+func init() {
+	common.RegisterParameters(TektonCmd, commands.GenerateTektonParamsConfig)
+}
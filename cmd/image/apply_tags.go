@@ -35,4 +35,5 @@ Both ways can be used together.
 
 func init() {
 	common.RegisterParameters(ApplyTagsCmd, commands.ApplyTagsParamsConfig)
+	common.RegisterResults(ApplyTagsCmd, commands.ApplyTagsResults{}, "KBC_APPLY_TAGS_RESULT_")
 }
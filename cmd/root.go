@@ -1,11 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/konflux-ci/konflux-build-cli/pkg/cliwrappers"
 	"github.com/konflux-ci/konflux-build-cli/pkg/common"
 	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
 )
@@ -14,6 +22,26 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "konflux-build-cli",
 	Short: "A helper CLI tool for Konflux build pipelines",
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		logResourceUsage()
+	},
+}
+
+var logResourceUsageOnce sync.Once
+
+// logResourceUsage prints resources consumed by external tools,
+// which helps to size resource requests of the Tekton steps.
+func logResourceUsage() {
+	logResourceUsageOnce.Do(func() {
+		usageReport := cliwrappers.ExecutedCommandsUsage.Format()
+		if usageReport == "" {
+			return
+		}
+		l.Logger.Info("Resource usage of executed commands:")
+		for _, line := range strings.Split(usageReport, "\n") {
+			l.Logger.Info("  " + line)
+		}
+	})
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -22,7 +50,12 @@ func Execute() {
 	processedArgs := common.ExpandArrayParameters(os.Args[1:])
 	rootCmd.SetArgs(processedArgs)
 
-	err := rootCmd.Execute()
+	// Cancel the command context on SIGINT or SIGTERM,
+	// so running child processes are terminated gracefully.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
 		os.Exit(1)
 	}
@@ -32,6 +65,19 @@ func init() {
 	// Common flags for all subcommands
 	var logLevel string
 	rootCmd.PersistentFlags().StringVar(&logLevel, "loglevel", "info", "Set the logging level (debug, info, warn, error, fatal)")
+	var dryRun bool
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print commands that would modify anything as a shell script to stderr instead of running them. Read-only commands are still run.")
+	var dryRunScriptPath string
+	rootCmd.PersistentFlags().StringVar(&dryRunScriptPath, "dry-run-script", "", "File to write the dry-run shell script into instead of stderr")
+	var configPath string
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "YAML or JSON file with parameter values by command path, used for parameters given neither as flags nor env vars")
+	var resultsDir string
+	rootCmd.PersistentFlags().StringVar(&resultsDir, "results-dir", "", "Directory to write results into, e.g. /tekton/results, used for results without their own --result-* path")
+	resultsLimits := common.ResultsLimits{}
+	rootCmd.PersistentFlags().IntVar(&resultsLimits.MaxSize, "results-max-size", 0, "Maximum size of a result in bytes, 0 for unlimited")
+	var resultsOverflow string
+	rootCmd.PersistentFlags().StringVar(&resultsOverflow, "results-overflow", string(common.ResultsOverflowFail), "What to do with results bigger than the maximum size: fail, or spill string results into --results-spill-dir and write the file path into the result")
+	rootCmd.PersistentFlags().StringVar(&resultsLimits.SpillDir, "results-spill-dir", "", "Directory to write results bigger than the maximum size into, e.g. on a workspace")
 
 	cobra.OnInitialize(func() {
 		if !rootCmd.Flags().Changed("loglevel") {
@@ -45,8 +91,79 @@ func init() {
 			fmt.Printf("failed to init logger: %s", err.Error())
 			os.Exit(2)
 		}
+
+		if !rootCmd.Flags().Changed("dry-run") {
+			if dryRunEnv := os.Getenv("KBC_DRY_RUN"); dryRunEnv != "" {
+				var err error
+				if dryRun, err = strconv.ParseBool(dryRunEnv); err != nil {
+					l.Logger.Fatalf("invalid KBC_DRY_RUN value '%s': %s", dryRunEnv, err.Error())
+				}
+			}
+		}
+		if dryRun {
+			l.Logger.Warn("Dry-run mode, commands that modify anything are printed instead of run")
+			if !rootCmd.Flags().Changed("dry-run-script") {
+				dryRunScriptPath = os.Getenv("KBC_DRY_RUN_SCRIPT")
+			}
+			// Stdout is reserved for command output, e.g. results in JSON format.
+			dryRunScriptOut := os.Stderr
+			if dryRunScriptPath != "" {
+				var err error
+				if dryRunScriptOut, err = os.Create(dryRunScriptPath); err != nil {
+					l.Logger.Fatalf("failed to create dry-run script: %s", err.Error())
+				}
+			}
+			cliwrappers.EnableDryRun(dryRunScriptOut)
+		}
+
+		if !rootCmd.Flags().Changed("config") {
+			configPath = os.Getenv("KBC_CONFIG")
+		}
+		if configPath != "" {
+			configFile, err := common.LoadConfigFile(configPath)
+			if err != nil {
+				l.Logger.Fatal(err)
+			}
+			if err := configFile.Validate(); err != nil {
+				l.Logger.Fatal(err)
+			}
+			l.Logger.Debugf("Using config file '%s'", configPath)
+			common.SetConfigFile(configFile)
+		}
+
+		if !rootCmd.Flags().Changed("results-dir") {
+			resultsDir = os.Getenv("KBC_RESULTS_DIR")
+		}
+		common.SetResultsDir(resultsDir)
+
+		if !rootCmd.Flags().Changed("results-max-size") {
+			if maxSizeEnv := os.Getenv("KBC_RESULTS_MAX_SIZE"); maxSizeEnv != "" {
+				var err error
+				if resultsLimits.MaxSize, err = strconv.Atoi(maxSizeEnv); err != nil {
+					l.Logger.Fatalf("invalid KBC_RESULTS_MAX_SIZE value '%s': %s", maxSizeEnv, err.Error())
+				}
+			}
+		}
+		if !rootCmd.Flags().Changed("results-overflow") {
+			if overflowEnv := os.Getenv("KBC_RESULTS_OVERFLOW"); overflowEnv != "" {
+				resultsOverflow = overflowEnv
+			}
+		}
+		if !rootCmd.Flags().Changed("results-spill-dir") {
+			resultsLimits.SpillDir = os.Getenv("KBC_RESULTS_SPILL_DIR")
+		}
+		resultsLimits.Overflow = common.ResultsOverflow(resultsOverflow)
+		if err := common.SetResultsLimits(resultsLimits); err != nil {
+			l.Logger.Fatal(err)
+		}
 	})
 
+	// Commands fail via Logger.Fatal, report resource usage also in such case.
+	logrus.RegisterExitHandler(logResourceUsage)
+
 	// Add commands
 	rootCmd.AddCommand(imageCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(generateCmd)
 }
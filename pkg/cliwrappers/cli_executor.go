@@ -1,19 +1,28 @@
 package cliwrappers
 
 import (
-	"bufio"
 	"bytes"
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"syscall"
+	"time"
 
 	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
 )
 
 var executorLog = l.Logger.WithField("logger", "CliExecutor")
 
+// DefaultKillGracePeriod is the time given to a cancelled command to exit after SIGTERM before it's killed.
+const DefaultKillGracePeriod = 10 * time.Second
+
 type CliExecutorInterface interface {
 	Execute(command string, args ...string) (stdout, stderr string, exitCode int, err error)
 	ExecuteInDir(wordir, command string, args ...string) (stdout, stderr string, exitCode int, err error)
@@ -21,91 +30,365 @@ type CliExecutorInterface interface {
 	ExecuteInDirWithOutput(wordir, command string, args ...string) (stdout, stderr string, exitCode int, err error)
 }
 
+// CliExecutorContextInterface is implemented by executors that support cancellation and timeouts.
+// When the given context is done, the running command receives SIGTERM and then SIGKILL after a grace period.
+type CliExecutorContextInterface interface {
+	CliExecutorInterface
+	ExecuteContext(ctx context.Context, command string, args ...string) (stdout, stderr string, exitCode int, err error)
+	ExecuteInDirContext(ctx context.Context, workdir, command string, args ...string) (stdout, stderr string, exitCode int, err error)
+	ExecuteWithOutputContext(ctx context.Context, command string, args ...string) (stdout, stderr string, exitCode int, err error)
+	ExecuteInDirWithOutputContext(ctx context.Context, workdir, command string, args ...string) (stdout, stderr string, exitCode int, err error)
+}
+
+// CliExecutorWithOptionsInterface is implemented by executors that allow
+// to customize the command environment, input and output, see ExecOptions.
+type CliExecutorWithOptionsInterface interface {
+	CliExecutorInterface
+	ExecuteWithOptions(ctx context.Context, opts *ExecOptions, command string, args ...string) (*ExecResult, error)
+}
+
+// ExecOptions customizes a command execution.
+type ExecOptions struct {
+	// Dir is the working directory of the command, the current directory if empty.
+	Dir string
+	// Env contains environment variables to set for the command.
+	// By default, they are added to the current process environment, overriding existing values.
+	Env map[string]string
+	// ReplaceEnv makes Env the only environment of the command.
+	ReplaceEnv bool
+	// Stdin is the command input. The command reads from the null device if nil.
+	Stdin io.Reader
+	// Stdout receives the command standard output. If set, the output is not kept in the result.
+	Stdout io.Writer
+	// Stderr receives the command error output. If set, the output is not kept in the result.
+	Stderr io.Writer
+	// PrintOutput logs stdout and stderr of the command in real time.
+	PrintOutput bool
+	// SpillOutput limits the captured output kept in memory, see CliExecutor.MaxOutputInMemory.
+	// Bigger output is written into temporary files, which the caller must remove with ExecResult.Cleanup.
+	// Without it, the whole output is kept in memory.
+	SpillOutput bool
+}
+
+// ExecResult holds the outcome of a command execution.
+// If spilling is requested, see ExecOptions.SpillOutput, and an output exceeds the executor in-memory limit,
+// it's written into a temporary file and the result holds only its end, see CliExecutor.MaxOutputInMemory.
+type ExecResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	// StdoutFile is the file with the full stdout, if it exceeded the in-memory limit.
+	StdoutFile string
+	// StderrFile is the file with the full stderr, if it exceeded the in-memory limit.
+	StderrFile string
+	// Usage holds resources consumed by the command, nil if the command didn't start
+	// or the executor doesn't measure resources.
+	Usage *ResourceUsage
+}
+
+// environment returns the command environment, nil means the current process environment.
+func (o *ExecOptions) environment() []string {
+	if len(o.Env) == 0 && !o.ReplaceEnv {
+		return nil
+	}
+
+	var env []string
+	if !o.ReplaceEnv {
+		env = os.Environ()
+	}
+	// Later values take precedence, so the overlay overrides inherited variables.
+	for _, name := range slices.Sorted(maps.Keys(o.Env)) {
+		env = append(env, name+"="+o.Env[name])
+	}
+	if env == nil {
+		// Empty, but not nil, environment
+		env = []string{}
+	}
+	return env
+}
+
 var _ CliExecutorInterface = &CliExecutor{}
+var _ CliExecutorContextInterface = &CliExecutor{}
+var _ CliExecutorWithOptionsInterface = &CliExecutor{}
 
-type CliExecutor struct{}
+// CliExecutor runs external commands.
+// Commands run via methods without context parameter are bound to the executor context, see WithContext.
+type CliExecutor struct {
+	// Timeout limits each command execution, if positive.
+	Timeout time.Duration
+	// KillGracePeriod is the time between SIGTERM and SIGKILL sent to a cancelled command.
+	// For cancellable commands, it also limits reading output after the command exits,
+	// e.g. held open by a background process started by the command. Zero means DefaultKillGracePeriod.
+	KillGracePeriod time.Duration
+	// MaxOutputInMemory is the size of stdout and stderr each, kept in memory for executions with ExecOptions.SpillOutput.
+	// Bigger output is written into a temporary file and only its last OutputTailSize bytes are returned.
+	// Zero means DefaultMaxOutputInMemory, negative value means no limit.
+	// Methods returning plain output always return the whole output.
+	MaxOutputInMemory int
+	// OutputTailSize is the size of the output end returned when the output exceeds MaxOutputInMemory.
+	// Zero means DefaultOutputTailSize.
+	OutputTailSize int
+	// MaxLogLineLength limits the length of output lines printed by the *WithOutput methods.
+	// Longer lines are truncated. Zero means DefaultMaxLogLineLength, negative value means no limit.
+	MaxLogLineLength int
+
+	ctx context.Context
+}
 
 func NewCliExecutor() *CliExecutor {
-	return &CliExecutor{}
+	return &CliExecutor{
+		KillGracePeriod:   DefaultKillGracePeriod,
+		MaxOutputInMemory: DefaultMaxOutputInMemory,
+		OutputTailSize:    DefaultOutputTailSize,
+		MaxLogLineLength:  DefaultMaxLogLineLength,
+	}
+}
+
+// NewExecutor returns the executor configured for the current CLI run, bound to the given context.
+// Commands should use it instead of NewCliExecutor, so global options like --dry-run are respected.
+// The executor runs commands through the configured middlewares, e.g. logging and dry-run, see ChainExecutor.
+func NewExecutor(ctx context.Context) CliExecutorInterface {
+	return NewChainExecutor(NewCliExecutor().WithContext(ctx), executorMiddlewares()...)
+}
+
+// WithContext sets the context used for commands run without explicit context.
+// Usually, it's the command context which is cancelled when the CLI receives SIGINT or SIGTERM.
+func (e *CliExecutor) WithContext(ctx context.Context) *CliExecutor {
+	e.ctx = ctx
+	return e
+}
+
+// WithTimeout sets the maximum duration of each command execution.
+func (e *CliExecutor) WithTimeout(timeout time.Duration) *CliExecutor {
+	e.Timeout = timeout
+	return e
+}
+
+// WithKillGracePeriod sets the time given to a cancelled command to exit after SIGTERM before it's killed.
+func (e *CliExecutor) WithKillGracePeriod(gracePeriod time.Duration) *CliExecutor {
+	e.KillGracePeriod = gracePeriod
+	return e
+}
+
+// WithOutputLimits sets the in-memory size of each output and the size of the output end
+// returned when the output is bigger, see MaxOutputInMemory.
+func (e *CliExecutor) WithOutputLimits(maxOutputInMemory, outputTailSize int) *CliExecutor {
+	e.MaxOutputInMemory = maxOutputInMemory
+	e.OutputTailSize = outputTailSize
+	return e
+}
+
+// WithMaxLogLineLength sets the length of printed output lines, longer lines are truncated.
+func (e *CliExecutor) WithMaxLogLineLength(maxLogLineLength int) *CliExecutor {
+	e.MaxLogLineLength = maxLogLineLength
+	return e
+}
+
+// Context returns the executor context.
+func (e *CliExecutor) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
 }
 
 // Execute runs specified command with given arguments.
 // Returns stdout, stderr, exit code, error
 func (e *CliExecutor) Execute(command string, args ...string) (string, string, int, error) {
-	return e.ExecuteInDir("", command, args...)
+	return e.ExecuteInDirContext(e.Context(), "", command, args...)
+}
+
+// ExecuteContext runs specified command with given arguments until it finishes or the context is done.
+// Returns stdout, stderr, exit code, error
+func (e *CliExecutor) ExecuteContext(ctx context.Context, command string, args ...string) (string, string, int, error) {
+	return e.ExecuteInDirContext(ctx, "", command, args...)
 }
 
 // ExecuteInDir runs specified command in the given directory.
 // Returns stdout, stderr, exit code, error
 func (e *CliExecutor) ExecuteInDir(wordir, command string, args ...string) (string, string, int, error) {
-	cmd := exec.Command(command, args...)
-	if wordir != "" {
-		cmd.Dir = wordir
-	}
-
-	var stdoutBuf, stderrBuf bytes.Buffer
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
-
-	err := cmd.Run()
+	return e.ExecuteInDirContext(e.Context(), wordir, command, args...)
+}
 
-	return stdoutBuf.String(), stderrBuf.String(), getExitCodeFromError(err), err
+// ExecuteInDirContext runs specified command in the given directory until it finishes or the context is done.
+// Returns stdout, stderr, exit code, error
+func (e *CliExecutor) ExecuteInDirContext(ctx context.Context, workdir, command string, args ...string) (string, string, int, error) {
+	return unpackExecResult(e.ExecuteWithOptions(ctx, &ExecOptions{Dir: workdir}, command, args...))
 }
 
 // ExecuteWithOutput runs specified command with args while printing stdout and stderr in real time.
 // Returns stdout, stderr, exit code, error
 func (e *CliExecutor) ExecuteWithOutput(command string, args ...string) (string, string, int, error) {
-	return e.ExecuteInDirWithOutput("", command, args...)
+	return e.ExecuteInDirWithOutputContext(e.Context(), "", command, args...)
+}
+
+// ExecuteWithOutputContext runs specified command with args while printing stdout and stderr in real time,
+// until it finishes or the context is done.
+// Returns stdout, stderr, exit code, error
+func (e *CliExecutor) ExecuteWithOutputContext(ctx context.Context, command string, args ...string) (string, string, int, error) {
+	return e.ExecuteInDirWithOutputContext(ctx, "", command, args...)
 }
 
 // ExecuteInDirWithOutput runs specified command with args in given directory while printing stdout and stderr in real time.
 // Returns stdout, stderr, exit code, error
 func (e *CliExecutor) ExecuteInDirWithOutput(workdir, command string, args ...string) (stdout, stderr string, exitCode int, err error) {
-	cmd := exec.Command(command, args...)
-	if workdir != "" {
-		cmd.Dir = workdir
+	return e.ExecuteInDirWithOutputContext(e.Context(), workdir, command, args...)
+}
+
+// ExecuteInDirWithOutputContext runs specified command with args in given directory while printing stdout and stderr in real time,
+// until it finishes or the context is done.
+// Returns stdout, stderr, exit code, error
+func (e *CliExecutor) ExecuteInDirWithOutputContext(ctx context.Context, workdir, command string, args ...string) (stdout, stderr string, exitCode int, err error) {
+	return unpackExecResult(e.ExecuteWithOptions(ctx, &ExecOptions{Dir: workdir, PrintOutput: true}, command, args...))
+}
+
+// ExecuteWithOptions runs specified command with args according to the given options,
+// until it finishes or the context is done.
+// The returned result is never nil, exit code is -1 if the command could not be started or was killed.
+func (e *CliExecutor) ExecuteWithOptions(ctx context.Context, opts *ExecOptions, command string, args ...string) (*ExecResult, error) {
+	if opts == nil {
+		opts = &ExecOptions{}
+	}
+	if ctx == nil {
+		ctx = e.Context()
+	}
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+
+	registerArgSecrets(args)
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = opts.Dir
+	cmd.Env = opts.environment()
+	cmd.Stdin = opts.Stdin
+	cmd.Cancel = func() error {
+		executorLog.Infof("Terminating %s: %v", command, context.Cause(ctx))
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	// WaitDelay kills a command ignoring SIGTERM, but it also stops reading the output that long after the command exits,
+	// e.g. if a background process inherited stdout. So it's set only when the command can be cancelled.
+	if ctx.Done() != nil {
+		cmd.WaitDelay = cmp.Or(e.KillGracePeriod, DefaultKillGracePeriod)
 	}
 
-	stdoutPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return "", "", -1, fmt.Errorf("failed to get stdout: %w", err)
+	maxOutputInMemory := -1
+	if opts.SpillOutput {
+		maxOutputInMemory = cmp.Or(e.MaxOutputInMemory, DefaultMaxOutputInMemory)
+	}
+	outputTailSize := cmp.Or(e.OutputTailSize, DefaultOutputTailSize)
+	stdoutCapture := newOutputCapture(command+" stdout", maxOutputInMemory, outputTailSize)
+	stderrCapture := newOutputCapture(command+" stderr", maxOutputInMemory, outputTailSize)
+	defer stdoutCapture.Close()
+	defer stderrCapture.Close()
+	stdout, stderr := io.Writer(stdoutCapture), io.Writer(stderrCapture)
+	if opts.Stdout != nil {
+		stdout = opts.Stdout
 	}
-	stderrPipe, err := cmd.StderrPipe()
-	if err != nil {
-		return "", "", -1, fmt.Errorf("failed to get stderr: %w", err)
+	if opts.Stderr != nil {
+		stderr = opts.Stderr
 	}
+	if opts.PrintOutput {
+		maxLogLineLength := cmp.Or(e.MaxLogLineLength, DefaultMaxLogLineLength)
+		stdoutLogger := &lineLogger{prefix: command + " [stdout] ", maxLineLength: maxLogLineLength}
+		stderrLogger := &lineLogger{prefix: command + " [stderr] ", maxLineLength: maxLogLineLength}
+		defer stdoutLogger.Flush()
+		defer stderrLogger.Flush()
+		stdout = io.MultiWriter(stdout, stdoutLogger)
+		stderr = io.MultiWriter(stderr, stderrLogger)
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
-	if err := cmd.Start(); err != nil {
-		return "", "", -1, fmt.Errorf("failed to start command: %w", err)
+	start := time.Now()
+	err := cmd.Run()
+	usage := newResourceUsage(cmd.ProcessState, time.Since(start))
+	ExecutedCommandsUsage.Add(filepath.Base(command), usage)
+	if err != nil && ctx.Err() != nil {
+		// Make it possible to distinguish cancellation and timeout from the command failure.
+		err = fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+
+	result := &ExecResult{
+		Stdout:     stdoutCapture.String(),
+		Stderr:     stderrCapture.String(),
+		ExitCode:   getExitCodeFromError(err),
+		StdoutFile: stdoutCapture.Path(),
+		StderrFile: stderrCapture.Path(),
+		Usage:      usage,
+	}
+	return result, err
+}
+
+// Cleanup removes the files with full output, if any.
+func (r *ExecResult) Cleanup() {
+	for _, path := range []string{r.StdoutFile, r.StderrFile} {
+		if path != "" {
+			os.Remove(path)
+		}
 	}
+	r.StdoutFile, r.StderrFile = "", ""
+}
+
+// unpackExecResult converts the result for the methods returning plain output.
+// The result must hold the whole output, i.e. the execution must not use ExecOptions.SpillOutput.
+func unpackExecResult(result *ExecResult, err error) (string, string, int, error) {
+	return result.Stdout, result.Stderr, result.ExitCode, err
+}
 
-	var stdoutBuf, stderrBuf bytes.Buffer
+// lineLogger logs each complete line written into it.
+// Lines longer than maxLineLength, if positive, are truncated.
+type lineLogger struct {
+	prefix        string
+	maxLineLength int
+	buf           []byte
+	// truncated is the number of bytes dropped from the current line.
+	truncated int
+}
 
-	readStream := func(linePrefix string, r io.Reader, buf *bytes.Buffer) {
-		scanner := bufio.NewScanner(r)
-		for scanner.Scan() {
-			line := scanner.Text()
-			executorLog.Info(linePrefix + line)
-			buf.WriteString(line + "\n")
+func (w *lineLogger) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.appendToLine(p)
+			break
 		}
+		w.appendToLine(p[:i])
+		w.logLine()
+		p = p[i+1:]
 	}
+	return written, nil
+}
 
-	done := make(chan struct{}, 2)
-	go func() {
-		readStream(command+" [stdout] ", stdoutPipe, &stdoutBuf)
-		done <- struct{}{}
-	}()
-	go func() {
-		readStream(command+" [stderr] ", stderrPipe, &stderrBuf)
-		done <- struct{}{}
-	}()
+func (w *lineLogger) appendToLine(p []byte) {
+	if w.maxLineLength > 0 {
+		if room := w.maxLineLength - len(w.buf); room < len(p) {
+			room = max(room, 0)
+			w.truncated += len(p) - room
+			p = p[:room]
+		}
+	}
+	w.buf = append(w.buf, p...)
+}
 
-	err = cmd.Wait()
-	// Wait for both output streams to finish
-	<-done
-	<-done
+// Flush logs the last line if it's not terminated by a new line.
+func (w *lineLogger) Flush() {
+	if len(w.buf) > 0 || w.truncated > 0 {
+		w.logLine()
+	}
+}
 
-	return stdoutBuf.String(), stderrBuf.String(), getExitCodeFromError(err), err
+func (w *lineLogger) logLine() {
+	line := l.Redact(string(bytes.TrimSuffix(w.buf, []byte("\r"))))
+	if w.truncated > 0 {
+		line += fmt.Sprintf(" ... [%d bytes truncated]", w.truncated)
+	}
+	executorLog.Info(w.prefix + line)
+	w.buf = w.buf[:0]
+	w.truncated = 0
 }
 
 func getExitCodeFromError(cmdErr error) int {
@@ -122,6 +405,39 @@ func getExitCodeFromError(cmdErr error) int {
 	return -1
 }
 
+// ExecuteWith runs the command with the given options using the executor.
+// Executors that don't implement CliExecutorWithOptionsInterface, e.g. test mocks,
+// are supported as long as only Dir and PrintOutput options are used.
+func ExecuteWith(ctx context.Context, executor CliExecutorInterface, opts *ExecOptions, command string, args ...string) (*ExecResult, error) {
+	if e, ok := executor.(CliExecutorWithOptionsInterface); ok {
+		return e.ExecuteWithOptions(ctx, opts, command, args...)
+	}
+
+	if opts == nil {
+		opts = &ExecOptions{}
+	}
+	if len(opts.Env) != 0 || opts.ReplaceEnv || opts.Stdin != nil || opts.Stdout != nil || opts.Stderr != nil {
+		return &ExecResult{ExitCode: -1}, fmt.Errorf("executor %T does not support custom environment, input or output", executor)
+	}
+
+	var result ExecResult
+	var err error
+	if opts.PrintOutput {
+		result.Stdout, result.Stderr, result.ExitCode, err = executor.ExecuteInDirWithOutput(opts.Dir, command, args...)
+	} else {
+		result.Stdout, result.Stderr, result.ExitCode, err = executor.ExecuteInDir(opts.Dir, command, args...)
+	}
+	return &result, err
+}
+
+// executorContext returns the context of the given executor, if it has one.
+func executorContext(executor CliExecutorInterface) context.Context {
+	if e, ok := executor.(interface{ Context() context.Context }); ok {
+		return e.Context()
+	}
+	return context.Background()
+}
+
 func CheckCliToolAvailable(cliTool string) (bool, error) {
 	if _, err := exec.LookPath(cliTool); err != nil {
 		if errors.Is(err, exec.ErrNotFound) {
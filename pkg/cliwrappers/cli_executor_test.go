@@ -1,15 +1,20 @@
 package cliwrappers_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 
 	"github.com/konflux-ci/konflux-build-cli/pkg/cliwrappers"
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
 )
 
 func TestNewCliExecutor(t *testing.T) {
@@ -317,6 +322,344 @@ func TestCliExecutor_ExecuteInDirWithOutput(t *testing.T) {
 	})
 }
 
+func TestCliExecutor_ExecuteContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals are not supported on windows")
+	}
+
+	t.Run("should execute command with context", func(t *testing.T) {
+		g := NewWithT(t)
+
+		executor := cliwrappers.NewCliExecutor()
+
+		stdout, _, exitCode, err := executor.ExecuteContext(context.Background(), "echo", "test")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(exitCode).To(Equal(0))
+		g.Expect(strings.TrimSpace(stdout)).To(Equal("test"))
+	})
+
+	t.Run("should terminate command on context timeout", func(t *testing.T) {
+		g := NewWithT(t)
+
+		executor := cliwrappers.NewCliExecutor()
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, _, exitCode, err := executor.ExecuteContext(ctx, "sleep", "10")
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		g.Expect(exitCode).To(Equal(-1))
+		g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	})
+
+	t.Run("should kill command ignoring SIGTERM after grace period", func(t *testing.T) {
+		g := NewWithT(t)
+
+		executor := cliwrappers.NewCliExecutor().WithKillGracePeriod(200 * time.Millisecond)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(200*time.Millisecond, cancel)
+
+		start := time.Now()
+		stdout, _, _, err := executor.ExecuteWithOutputContext(ctx, "sh", "-c", "trap 'echo ignored' TERM; echo started; while true; do sleep 0.1; done")
+		elapsed := time.Since(start)
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		g.Expect(stdout).To(ContainSubstring("started"))
+		g.Expect(elapsed).To(BeNumerically(">=", 400*time.Millisecond))
+		g.Expect(elapsed).To(BeNumerically("<", 5*time.Second))
+	})
+
+	t.Run("should wait for output of background process without cancellable context", func(t *testing.T) {
+		g := NewWithT(t)
+
+		executor := cliwrappers.NewCliExecutor().WithKillGracePeriod(100 * time.Millisecond)
+
+		stdout, _, exitCode, err := executor.ExecuteContext(context.Background(), "sh", "-c", "(sleep 0.5; echo background) & echo started")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(exitCode).To(Equal(0))
+		g.Expect(stdout).To(Equal("started\nbackground\n"))
+	})
+
+	t.Run("should apply executor timeout to each command", func(t *testing.T) {
+		g := NewWithT(t)
+
+		executor := cliwrappers.NewCliExecutor().WithTimeout(100 * time.Millisecond)
+
+		_, _, _, err := executor.Execute("sleep", "10")
+		g.Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+
+		_, _, exitCode, err := executor.Execute("echo", "fast")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(exitCode).To(Equal(0))
+	})
+
+	t.Run("should bind commands without explicit context to the executor context", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		executor := cliwrappers.NewCliExecutor().WithContext(ctx)
+
+		g.Expect(executor.Context()).To(Equal(ctx))
+
+		_, _, _, err := executor.ExecuteInDir("", "echo", "test")
+		g.Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+	})
+}
+
+func TestCliExecutor_ExecuteWithOptions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell commands are not available on windows")
+	}
+
+	t.Run("should work with empty options", func(t *testing.T) {
+		g := NewWithT(t)
+
+		executor := cliwrappers.NewCliExecutor()
+
+		result, err := executor.ExecuteWithOptions(context.Background(), nil, "echo", "test")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.ExitCode).To(Equal(0))
+		g.Expect(strings.TrimSpace(result.Stdout)).To(Equal("test"))
+		g.Expect(result.Stderr).To(BeEmpty())
+	})
+
+	t.Run("should add environment variables to the current environment", func(t *testing.T) {
+		g := NewWithT(t)
+
+		t.Setenv("KBC_TEST_INHERITED", "inherited")
+		t.Setenv("KBC_TEST_OVERRIDDEN", "old")
+		executor := cliwrappers.NewCliExecutor()
+		opts := &cliwrappers.ExecOptions{
+			Env: map[string]string{
+				"KBC_TEST_OVERRIDDEN": "new",
+				"KBC_TEST_ADDED":      "added",
+			},
+		}
+
+		result, err := executor.ExecuteWithOptions(context.Background(), opts, "sh", "-c", "echo $KBC_TEST_INHERITED $KBC_TEST_OVERRIDDEN $KBC_TEST_ADDED")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(strings.TrimSpace(result.Stdout)).To(Equal("inherited new added"))
+	})
+
+	t.Run("should replace environment", func(t *testing.T) {
+		g := NewWithT(t)
+
+		t.Setenv("KBC_TEST_INHERITED", "inherited")
+		executor := cliwrappers.NewCliExecutor()
+		opts := &cliwrappers.ExecOptions{
+			Env:        map[string]string{"KBC_TEST_ADDED": "added"},
+			ReplaceEnv: true,
+		}
+
+		result, err := executor.ExecuteWithOptions(context.Background(), opts, "/bin/sh", "-c", "echo \"[$KBC_TEST_INHERITED] [$KBC_TEST_ADDED]\"")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(strings.TrimSpace(result.Stdout)).To(Equal("[] [added]"))
+	})
+
+	t.Run("should feed stdin", func(t *testing.T) {
+		g := NewWithT(t)
+
+		executor := cliwrappers.NewCliExecutor()
+		opts := &cliwrappers.ExecOptions{
+			Stdin: strings.NewReader("line from stdin\n"),
+		}
+
+		result, err := executor.ExecuteWithOptions(context.Background(), opts, "cat")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Stdout).To(Equal("line from stdin\n"))
+	})
+
+	t.Run("should write output into given writers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		outputFile := filepath.Join(t.TempDir(), "stdout.txt")
+		stdoutFile, err := os.Create(outputFile)
+		g.Expect(err).ToNot(HaveOccurred())
+		defer stdoutFile.Close()
+		var stderrBuf bytes.Buffer
+
+		executor := cliwrappers.NewCliExecutor()
+		opts := &cliwrappers.ExecOptions{
+			Stdout:      stdoutFile,
+			Stderr:      &stderrBuf,
+			PrintOutput: true,
+		}
+
+		result, err := executor.ExecuteWithOptions(context.Background(), opts, "sh", "-c", "echo 'to stdout'; echo 'to stderr' >&2")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Stdout).To(BeEmpty())
+		g.Expect(result.Stderr).To(BeEmpty())
+		g.Expect(stderrBuf.String()).To(Equal("to stderr\n"))
+		content, err := os.ReadFile(outputFile)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(content)).To(Equal("to stdout\n"))
+	})
+
+	t.Run("should run in given directory", func(t *testing.T) {
+		g := NewWithT(t)
+
+		tempDir := t.TempDir()
+		executor := cliwrappers.NewCliExecutor()
+
+		result, err := executor.ExecuteWithOptions(context.Background(), &cliwrappers.ExecOptions{Dir: tempDir}, "pwd")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		resolvedTempDir, _ := filepath.EvalSymlinks(tempDir)
+		g.Expect(strings.TrimSpace(result.Stdout)).To(Equal(resolvedTempDir))
+	})
+
+	t.Run("should return exit code of failed command", func(t *testing.T) {
+		g := NewWithT(t)
+
+		executor := cliwrappers.NewCliExecutor()
+
+		result, err := executor.ExecuteWithOptions(context.Background(), &cliwrappers.ExecOptions{}, "sh", "-c", "echo failure >&2; exit 3")
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(result.ExitCode).To(Equal(3))
+		g.Expect(result.Stderr).To(Equal("failure\n"))
+	})
+}
+
+func TestExecuteWith(t *testing.T) {
+	t.Run("should use options aware executor", func(t *testing.T) {
+		g := NewWithT(t)
+
+		result, err := cliwrappers.ExecuteWith(context.Background(), cliwrappers.NewCliExecutor(),
+			&cliwrappers.ExecOptions{Env: map[string]string{"KBC_TEST_VAR": "value"}}, "sh", "-c", "echo $KBC_TEST_VAR")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(strings.TrimSpace(result.Stdout)).To(Equal("value"))
+	})
+
+	t.Run("should fall back to basic executor methods", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var capturedWorkdir string
+		executor := &mockExecutor{
+			executeInDirFunc: func(workdir, command string, args ...string) (string, string, int, error) {
+				capturedWorkdir = workdir
+				return "out", "err", 2, errors.New("failed")
+			},
+		}
+
+		result, err := cliwrappers.ExecuteWith(context.Background(), executor, &cliwrappers.ExecOptions{Dir: "/some/dir"}, "tool", "arg")
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(capturedWorkdir).To(Equal("/some/dir"))
+		g.Expect(result).To(Equal(&cliwrappers.ExecResult{Stdout: "out", Stderr: "err", ExitCode: 2}))
+	})
+
+	t.Run("should fail if basic executor cannot handle options", func(t *testing.T) {
+		g := NewWithT(t)
+
+		isExecuteCalled := false
+		executor := &mockExecutor{
+			executeInDirFunc: func(workdir, command string, args ...string) (string, string, int, error) {
+				isExecuteCalled = true
+				return "", "", 0, nil
+			},
+		}
+
+		result, err := cliwrappers.ExecuteWith(context.Background(), executor, &cliwrappers.ExecOptions{Stdin: strings.NewReader("input")}, "tool")
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(result.ExitCode).To(Equal(-1))
+		g.Expect(isExecuteCalled).To(BeFalse())
+	})
+}
+
+func TestCliExecutor_OutputLimits(t *testing.T) {
+	t.Run("should keep output within the limit in memory", func(t *testing.T) {
+		g := NewWithT(t)
+		executor := cliwrappers.NewCliExecutor().WithOutputLimits(100, 20)
+
+		result, err := executor.ExecuteWithOptions(context.Background(), &cliwrappers.ExecOptions{SpillOutput: true}, "echo", "short output")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Stdout).To(Equal("short output\n"))
+		g.Expect(result.StdoutFile).To(BeEmpty())
+	})
+
+	t.Run("should spill big output into a file and return its end", func(t *testing.T) {
+		g := NewWithT(t)
+		executor := cliwrappers.NewCliExecutor().WithOutputLimits(100, 20)
+
+		result, err := executor.ExecuteWithOptions(context.Background(), &cliwrappers.ExecOptions{SpillOutput: true}, "sh", "-c", "seq 1 1000; echo error >&2")
+		g.Expect(err).ToNot(HaveOccurred())
+		defer result.Cleanup()
+
+		g.Expect(result.StdoutFile).ToNot(BeEmpty())
+		fullOutput, err := os.ReadFile(result.StdoutFile)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(strings.Split(strings.TrimSpace(string(fullOutput)), "\n")).To(HaveLen(1000))
+
+		g.Expect(result.Stdout).To(HavePrefix("["))
+		g.Expect(result.Stdout).To(ContainSubstring("bytes of sh stdout truncated, full output in " + result.StdoutFile))
+		g.Expect(result.Stdout).To(HaveSuffix("\n998\n999\n1000\n"))
+		g.Expect(len(result.Stdout)).To(BeNumerically("<", 200))
+
+		g.Expect(result.Stderr).To(Equal("error\n"))
+		g.Expect(result.StderrFile).To(BeEmpty())
+
+		stdoutFile := result.StdoutFile
+		result.Cleanup()
+		g.Expect(stdoutFile).ToNot(BeAnExistingFile())
+	})
+
+	t.Run("should not limit output if the limit is negative", func(t *testing.T) {
+		g := NewWithT(t)
+		executor := cliwrappers.NewCliExecutor().WithOutputLimits(-1, 20)
+
+		result, err := executor.ExecuteWithOptions(context.Background(), &cliwrappers.ExecOptions{SpillOutput: true}, "seq", "1", "1000")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.StdoutFile).To(BeEmpty())
+		g.Expect(strings.Split(strings.TrimSpace(result.Stdout), "\n")).To(HaveLen(1000))
+	})
+
+	t.Run("should return whole output if spilling is not requested", func(t *testing.T) {
+		g := NewWithT(t)
+		executor := cliwrappers.NewCliExecutor().WithOutputLimits(100, 20)
+
+		result, err := executor.ExecuteWithOptions(context.Background(), nil, "seq", "1", "1000")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.StdoutFile).To(BeEmpty())
+		g.Expect(strings.Split(strings.TrimSpace(result.Stdout), "\n")).To(HaveLen(1000))
+
+		stdout, _, _, err := executor.Execute("seq", "1", "1000")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(strings.Split(strings.TrimSpace(stdout), "\n")).To(HaveLen(1000))
+	})
+
+	t.Run("should truncate long printed lines instead of dropping them", func(t *testing.T) {
+		g := NewWithT(t)
+		var logs bytes.Buffer
+		l.Logger.SetOutput(&logs)
+		defer l.Logger.SetOutput(os.Stderr)
+		executor := cliwrappers.NewCliExecutor().WithMaxLogLineLength(10)
+
+		longLine := strings.Repeat("x", 200*1024)
+		stdout, _, _, err := executor.ExecuteWithOutput("sh", "-c", "head -c 204800 /dev/zero | tr '\\0' x; echo; echo short")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(stdout).To(Equal(longLine + "\nshort\n"))
+		g.Expect(logs.String()).To(ContainSubstring("xxxxxxxxxx ... [204790 bytes truncated]"))
+		g.Expect(logs.String()).To(ContainSubstring("[stdout] short"))
+	})
+}
+
 func TestCheckCliToolAvailable(t *testing.T) {
 	t.Run("should return true for available CLI tool", func(t *testing.T) {
 		g := NewWithT(t)
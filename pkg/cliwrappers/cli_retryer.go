@@ -1,6 +1,8 @@
 package cliwrappers
 
 import (
+	"context"
+	"fmt"
 	"regexp"
 	"slices"
 	"time"
@@ -10,114 +12,132 @@ import (
 
 var retryerLog = l.Logger.WithField("logger", "Retryer")
 
-// Backdoor for tests
-var DisableRetryer bool = false
-
 // Retryer runs given command until it succeeds or a stop condition is met.
-// After the first failure, it waits BaseDelay before next attempt.
-// After each next failure, the dalay is multiplied by DelayFactor,
-// but cannot be greather than MaxDelay if MaxDelay is positive.
-// Stop conditions:
-// - MaxAttempts is reached
+// The delays and common stop conditions are defined by the embedded RetryPolicy.
+// Additional stop conditions:
 // - The command exited with a stop exit code
 // - The command output (stdout or stderr) contained a stop substring or matched a stop regexp.
+// If the classifier reports rate limiting and the tool printed Retry-After time, the delay is at least that long.
 type Retryer struct {
-	BaseDelay   time.Duration
-	DelayFactor float64
-	MaxAttempts int
-	MaxDelay    time.Duration
+	RetryPolicy
 
 	cliCall func() (stdout string, stderr string, errCode int, err error)
 
 	stopExitCodes   []int
 	stopErrorRegexs []*regexp.Regexp
+	cliClassifier   ErrorClassifier
+}
+
+// RetryResult holds output of the last attempt and history of all attempts.
+type RetryResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Attempts []RetryAttempt
 }
 
 func NewRetryer(cliCall func() (stdout string, stderr string, errCode int, err error)) *Retryer {
 	return &Retryer{
-		BaseDelay:   1 * time.Second,
-		DelayFactor: 2,
-		MaxAttempts: 3,
-
-		cliCall: cliCall,
+		RetryPolicy: *NewRetryPolicy(),
+		cliCall:     cliCall,
 	}
 }
 
 // Run executes the provided via constructor command with specified retries strategy.
 // Returns stdout, stderr, errCode, error of the last run.
 func (r *Retryer) Run() (stdout string, stderr string, errCode int, err error) {
-	if DisableRetryer {
-		return r.cliCall()
-	}
-
-	retryerLog.Debugf("Running with max retries %d, %v interval, %.2f interval factor", r.MaxAttempts, r.BaseDelay, r.DelayFactor)
+	result, err := r.RunWithHistory()
+	return result.Stdout, result.Stderr, result.ExitCode, err
+}
 
-	delay := r.BaseDelay
-	for attempt := 1; attempt <= r.MaxAttempts; attempt++ {
-		stdout, stderr, errCode, err = r.cliCall()
-		if err == nil {
-			return
-		}
+// RunWithHistory executes the provided via constructor command with specified retries strategy.
+// Returns output of the last run together with history of all attempts. The result is never nil.
+func (r *Retryer) RunWithHistory() (*RetryResult, error) {
+	call := func(ctx context.Context) (*RetryResult, error) {
+		result := &RetryResult{}
+		var err error
+		result.Stdout, result.Stderr, result.ExitCode, err = r.cliCall()
+		return result, err
+	}
 
-		if slices.Contains(r.stopExitCodes, errCode) {
-			retryerLog.Debugf("Stopping retries after attempt %d, because cli exited with return code: %d", attempt, errCode)
-			return
-		}
-		for _, stopRegex := range r.stopErrorRegexs {
-			if stopRegex.MatchString(stdout) || stopRegex.MatchString(stderr) {
-				retryerLog.Debugf("Stopping retries after attempt %d, because cli output matched stop regex: %s", attempt, stopRegex.String())
-				return
+	hooks := retryHooks[*RetryResult]{
+		exitCode: func(result *RetryResult, _ error) int {
+			return result.ExitCode
+		},
+		stopReason: func(result *RetryResult, _ error) string {
+			if slices.Contains(r.stopExitCodes, result.ExitCode) {
+				return fmt.Sprintf("cli exited with return code: %d", result.ExitCode)
 			}
+			for _, stopRegex := range r.stopErrorRegexs {
+				if stopRegex.MatchString(result.Stdout) || stopRegex.MatchString(result.Stderr) {
+					return "cli output matched stop regex: " + stopRegex.String()
+				}
+			}
+			return ""
+		},
+		retryAfter: func(result *RetryResult, _ error) time.Duration {
+			return parseRetryAfter(result.Stdout, result.Stderr)
+		},
+		describeFailure: func(result *RetryResult, _ error) string {
+			return fmt.Sprintf("output:\n[stdout]:\n%s\n[stderr]:\n%s", result.Stdout, result.Stderr)
+		},
+	}
+	if r.cliClassifier != nil {
+		hooks.classify = func(result *RetryResult, err error) ErrorClass {
+			return r.cliClassifier(result.Stdout, result.Stderr, result.ExitCode, err)
 		}
+	}
 
-		if attempt == r.MaxAttempts {
-			// It was the last iteration, no need to wait after it.
-			retryerLog.Debugf("Attempt %d failed, output:\n[stdout]:\n%s\n[stderr]:\n%s", attempt, stdout, stderr)
-			break
-		}
-
-		retryerLog.Debugf("Attempt %d failed, output:\n[stdout]:\n%s\n[stderr]:\n%s\nWaiting %v before next retry", attempt, stdout, stderr, delay)
-		time.Sleep(delay)
-		delay = time.Duration(float64(delay) * r.DelayFactor)
-		if r.MaxDelay > 0 && delay > r.MaxDelay {
-			delay = r.MaxDelay
-		}
+	result, attempts, err := runWithRetries(&r.RetryPolicy, call, hooks)
+	if result == nil {
+		result = &RetryResult{ExitCode: -1}
 	}
+	result.Attempts = attempts
+	return result, err
+}
+
+// WithContext binds the retries to the given context.
+// No more attempts are performed after the context is done or if the next attempt would start after the context deadline.
+func (r *Retryer) WithContext(ctx context.Context) *Retryer {
+	r.RetryPolicy.WithContext(ctx)
+	return r
+}
 
-	retryerLog.Infof("Giving up on command after %d attempts", r.MaxAttempts)
-	return
+// WithClock sets the clock used for delays and time measurements.
+func (r *Retryer) WithClock(clock Clock) *Retryer {
+	r.RetryPolicy.WithClock(clock)
+	return r
 }
 
 // WithBaseDelay sets the initial delay after a failure.
 // The delay will be increased by DelayFactor times after each failure.
 func (r *Retryer) WithBaseDelay(baseInterval time.Duration) *Retryer {
-	r.BaseDelay = baseInterval
+	r.RetryPolicy.WithBaseDelay(baseInterval)
 	return r
 }
 
 // WithDelayFactor sets the delay increasing factor after a failure.
 func (r *Retryer) WithDelayFactor(delayFactor float64) *Retryer {
-	r.DelayFactor = delayFactor
+	r.RetryPolicy.WithDelayFactor(delayFactor)
 	return r
 }
 
 // WithConstantDelay makes all dalays after failures of the same duration.
 func (r *Retryer) WithConstantDelay(delay time.Duration) *Retryer {
-	r.BaseDelay = delay
-	r.DelayFactor = 1
+	r.RetryPolicy.WithConstantDelay(delay)
 	return r
 }
 
 // WithMaxAttempts sets maximum number or attempts before give up and fail.
 func (r *Retryer) WithMaxAttempts(maxAttempts int) *Retryer {
-	r.MaxAttempts = maxAttempts
+	r.RetryPolicy.WithMaxAttempts(maxAttempts)
 	return r
 }
 
 // WithMaxDelay sets maximum delay to wait between attempts.
 // If not set, no limit is appled.
 func (r *Retryer) WithMaxDelay(maxDelay time.Duration) *Retryer {
-	r.MaxDelay = maxDelay
+	r.RetryPolicy.WithMaxDelay(maxDelay)
 	return r
 }
 
@@ -151,11 +171,41 @@ func (r *Retryer) StopIfOutputContains(stopString string) *Retryer {
 	return r.StopIfOutputMatches("(?i)" + regexp.QuoteMeta(stopString))
 }
 
+// WithMaxDuration limits the total time spent on all attempts and delays between them.
+func (r *Retryer) WithMaxDuration(maxDuration time.Duration) *Retryer {
+	r.RetryPolicy.WithMaxDuration(maxDuration)
+	return r
+}
+
+// WithFullJitter makes each delay a random time between zero and the exponential delay.
+func (r *Retryer) WithFullJitter() *Retryer {
+	r.RetryPolicy.WithFullJitter()
+	return r
+}
+
+// WithDecorrelatedJitter makes each delay a random time between BaseDelay and three times the previous delay.
+// MaxDelay, if set, still applies.
+func (r *Retryer) WithDecorrelatedJitter() *Retryer {
+	r.RetryPolicy.WithDecorrelatedJitter()
+	return r
+}
+
+// WithStatistics makes the retryer add history of each run into the given statistics.
+func (r *Retryer) WithStatistics(statistics *RetryStatistics) *Retryer {
+	r.RetryPolicy.WithStatistics(statistics)
+	return r
+}
+
+// WithErrorClassifier sets the classifier which determines whether a failure is worth retrying.
+func (r *Retryer) WithErrorClassifier(classifier ErrorClassifier) *Retryer {
+	r.cliClassifier = classifier
+	return r
+}
+
 // WithImageRegistryPreset sets retryer parameters for interacting with an image registry scenario.
+// Failures that cannot be fixed by retrying, like authentication or not found errors, are not retried.
 func (r *Retryer) WithImageRegistryPreset() *Retryer {
-	r.BaseDelay = 1 * time.Second
-	r.DelayFactor = 2
-	r.MaxAttempts = 10
-	r.MaxDelay = 4 * time.Minute
+	r.RetryPolicy.WithImageRegistryPreset()
+	r.cliClassifier = ClassifyRegistryError
 	return r
 }
@@ -1,6 +1,7 @@
 package cliwrappers_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -314,4 +315,246 @@ func TestRetryer_Run(t *testing.T) {
 		g.Expect(stderr).To(Equal(stopStderr))
 		g.Expect(attempt).To(Equal(returnStopStringAtAttempt))
 	})
+
+	t.Run("should not run command if context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		attempt := 0
+		retryer := cliwrappers.NewRetryer(func() (string, string, int, error) {
+			attempt++
+			return "", "", 0, nil
+		}).WithContext(ctx)
+
+		_, _, exitCode, err := retryer.Run()
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		g.Expect(exitCode).To(Equal(-1))
+		g.Expect(attempt).To(Equal(0))
+	})
+
+	t.Run("should stop retries when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		attempt := 0
+		retryer := cliwrappers.NewRetryer(func() (string, string, int, error) {
+			attempt++
+			if attempt == 2 {
+				cancel()
+			}
+			return "", "", 1, errors.New("command has failed")
+		}).WithContext(ctx).WithConstantDelay(time.Second).WithMaxAttempts(10)
+
+		start := time.Now()
+		_, _, _, err := retryer.Run()
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(attempt).To(Equal(2))
+		g.Expect(time.Since(start)).To(BeNumerically("<", 1500*time.Millisecond))
+	})
+
+	t.Run("should not start attempt after context deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		attempt := 0
+		retryer := cliwrappers.NewRetryer(func() (string, string, int, error) {
+			attempt++
+			return "", "", 1, errors.New("command has failed")
+		}).WithContext(ctx).WithConstantDelay(20 * time.Millisecond).WithMaxAttempts(10)
+
+		start := time.Now()
+		_, _, _, err := retryer.Run()
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(attempt).To(BeNumerically(">=", 2))
+		g.Expect(attempt).To(BeNumerically("<", 4))
+		g.Expect(time.Since(start)).To(BeNumerically("<", 50*time.Millisecond))
+	})
+
+	t.Run("should not retry non-retryable failures", func(t *testing.T) {
+		attempt := 0
+		retryer := cliwrappers.NewRetryer(func() (string, string, int, error) {
+			attempt++
+			if attempt == 1 {
+				return "", "received unexpected HTTP status: 503 Service Unavailable", 1, errors.New("exit status 1")
+			}
+			return "", "reading manifest tag in quay.io/org/image: manifest unknown", 1, errors.New("exit status 1")
+		}).WithConstantDelay(1 * time.Millisecond).WithMaxAttempts(10).
+			WithErrorClassifier(cliwrappers.ClassifyRegistryError)
+
+		_, _, _, err := retryer.Run()
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(attempt).To(Equal(2))
+		var classifiedErr *cliwrappers.ClassifiedError
+		g.Expect(errors.As(err, &classifiedErr)).To(BeTrue())
+		g.Expect(classifiedErr.Class).To(Equal(cliwrappers.ErrorClassNotFound))
+	})
+
+	t.Run("should return class of the last failure after max attempts", func(t *testing.T) {
+		attempt := 0
+		retryer := cliwrappers.NewRetryer(func() (string, string, int, error) {
+			attempt++
+			return "", "dial tcp: lookup quay.io: no such host", 1, errors.New("exit status 1")
+		}).WithConstantDelay(1 * time.Millisecond).WithMaxAttempts(3).
+			WithErrorClassifier(cliwrappers.ClassifyRegistryError)
+
+		_, _, _, err := retryer.Run()
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(attempt).To(Equal(3))
+		g.Expect(cliwrappers.ErrorClassOf(err)).To(Equal(cliwrappers.ErrorClassNetwork))
+	})
+
+	t.Run("should wait at least the time requested by rate limiting server", func(t *testing.T) {
+		attempt := 0
+		retryer := cliwrappers.NewRetryer(func() (string, string, int, error) {
+			attempt++
+			if attempt == 1 {
+				return "", "429 Too Many Requests, Retry-After: 1", 1, errors.New("exit status 1")
+			}
+			return "", "", 0, nil
+		}).WithConstantDelay(1 * time.Millisecond).WithMaxAttempts(3).
+			WithErrorClassifier(cliwrappers.ClassifyRegistryError)
+
+		start := time.Now()
+		_, _, _, err := retryer.Run()
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(attempt).To(Equal(2))
+		g.Expect(time.Since(start)).To(BeNumerically(">=", 1*time.Second))
+	})
+
+	t.Run("should not wrap errors without classifier", func(t *testing.T) {
+		cliErr := errors.New("exit status 1")
+		retryer := cliwrappers.NewRetryer(func() (string, string, int, error) {
+			return "", "unauthorized", 1, cliErr
+		}).WithConstantDelay(1 * time.Millisecond).WithMaxAttempts(2)
+
+		_, _, _, err := retryer.Run()
+
+		g.Expect(err).To(Equal(cliErr))
+	})
+
+	t.Run("should stop retries when total time budget is exceeded", func(t *testing.T) {
+		attempt := 0
+		retryer := cliwrappers.NewRetryer(func() (string, string, int, error) {
+			attempt++
+			return "", "", 1, errors.New("command has failed")
+		}).WithConstantDelay(20 * time.Millisecond).WithMaxAttempts(100).WithMaxDuration(50 * time.Millisecond)
+
+		start := time.Now()
+		_, _, _, err := retryer.Run()
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(attempt).To(BeNumerically(">=", 2))
+		g.Expect(attempt).To(BeNumerically("<", 4))
+		g.Expect(time.Since(start)).To(BeNumerically("<", 50*time.Millisecond))
+	})
+
+	t.Run("should return history of all attempts", func(t *testing.T) {
+		attempt := 0
+		retryer := cliwrappers.NewRetryer(func() (string, string, int, error) {
+			attempt++
+			switch attempt {
+			case 1:
+				return "", "503 Service Unavailable", 1, errors.New("exit status 1")
+			case 2:
+				return "", "connection refused", 2, errors.New("exit status 2")
+			}
+			return "done", "", 0, nil
+		}).WithConstantDelay(1 * time.Millisecond).WithMaxAttempts(5).
+			WithErrorClassifier(cliwrappers.ClassifyRegistryError)
+
+		start := time.Now()
+		result, err := retryer.RunWithHistory()
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Stdout).To(Equal("done"))
+		g.Expect(result.ExitCode).To(Equal(0))
+		g.Expect(result.Attempts).To(HaveLen(3))
+		g.Expect(result.Attempts[0].ExitCode).To(Equal(1))
+		g.Expect(result.Attempts[0].Class).To(Equal(cliwrappers.ErrorClassServer))
+		g.Expect(result.Attempts[0].Start).To(BeTemporally(">=", start))
+		g.Expect(result.Attempts[1].ExitCode).To(Equal(2))
+		g.Expect(result.Attempts[1].Class).To(Equal(cliwrappers.ErrorClassNetwork))
+		g.Expect(result.Attempts[1].Start).To(BeTemporally(">", result.Attempts[0].Start))
+		g.Expect(result.Attempts[2].ExitCode).To(Equal(0))
+		g.Expect(result.Attempts[2].Class).To(BeEmpty())
+	})
+
+	t.Run("should add history into statistics", func(t *testing.T) {
+		statistics := cliwrappers.NewRetryStatistics()
+		attempt := 0
+		cliCall := func() (string, string, int, error) {
+			attempt++
+			if attempt%2 == 1 {
+				return "", "502 Bad Gateway", 1, errors.New("exit status 1")
+			}
+			return "", "", 0, nil
+		}
+
+		for i := 0; i < 2; i++ {
+			_, _, _, err := cliwrappers.NewRetryer(cliCall).WithConstantDelay(1 * time.Millisecond).
+				WithErrorClassifier(cliwrappers.ClassifyRegistryError).WithStatistics(statistics).Run()
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		summary := statistics.Summary()
+		g.Expect(summary.Commands).To(Equal(2))
+		g.Expect(summary.Attempts).To(Equal(4))
+		g.Expect(summary.Retries).To(Equal(2))
+		g.Expect(summary.TotalDuration).To(BeNumerically(">=", 2*time.Millisecond))
+		g.Expect(summary.FailuresByClass).To(Equal(map[cliwrappers.ErrorClass]int{cliwrappers.ErrorClassServer: 2}))
+	})
+}
+
+func TestRetryer_Jitter(t *testing.T) {
+	g := NewWithT(t)
+
+	// measureDelays returns delays between attempts of a command that always fails.
+	measureDelays := func(retryer *cliwrappers.Retryer) []time.Duration {
+		clock := cliwrappers.NewFakeClock(time.Now())
+		_, _ = retryer.WithClock(clock).RunWithHistory()
+		return clock.Sleeps()
+	}
+	failingCall := func() (string, string, int, error) {
+		return "", "", 1, errors.New("command has failed")
+	}
+
+	t.Run("should be able to set jitter", func(t *testing.T) {
+		g.Expect(cliwrappers.NewRetryer(failingCall).Jitter).To(Equal(cliwrappers.JitterNone))
+		g.Expect(cliwrappers.NewRetryer(failingCall).WithFullJitter().Jitter).To(Equal(cliwrappers.JitterFull))
+		g.Expect(cliwrappers.NewRetryer(failingCall).WithDecorrelatedJitter().Jitter).To(Equal(cliwrappers.JitterDecorrelated))
+	})
+
+	t.Run("should not exceed exponential delay with full jitter", func(t *testing.T) {
+		retryer := cliwrappers.NewRetryer(failingCall).WithMaxAttempts(5).
+			WithBaseDelay(5 * time.Millisecond).WithDelayFactor(2).WithFullJitter()
+
+		delays := measureDelays(retryer)
+
+		g.Expect(delays).To(HaveLen(4))
+		for i, delay := range delays {
+			g.Expect(delay).To(BeNumerically("<=", 5*time.Millisecond<<i))
+		}
+	})
+
+	t.Run("should keep decorrelated jitter delays within limits", func(t *testing.T) {
+		const baseDelay = 2 * time.Millisecond
+		const maxDelay = 15 * time.Millisecond
+		retryer := cliwrappers.NewRetryer(failingCall).WithMaxAttempts(8).
+			WithBaseDelay(baseDelay).WithMaxDelay(maxDelay).WithDecorrelatedJitter()
+
+		delays := measureDelays(retryer)
+
+		g.Expect(delays).To(HaveLen(7))
+		for _, delay := range delays {
+			g.Expect(delay).To(BeNumerically(">=", baseDelay))
+			g.Expect(delay).To(BeNumerically("<=", maxDelay))
+		}
+	})
 }
@@ -0,0 +1,134 @@
+package cliwrappers
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+var dryRunLog = l.Logger.WithField("logger", "DryRunExecutor")
+
+// readOnlySubcommands lists subcommands of known tools that do not modify anything.
+var readOnlySubcommands = map[string][]string{
+	"skopeo":  {"inspect", "list-tags"},
+	"git":     {"status", "log", "show", "diff", "rev-parse", "ls-remote", "describe"},
+	"buildah": {"inspect", "images", "info"},
+	"podman":  {"inspect", "images", "info"},
+}
+
+// readOnlyArgs are treated as read-only for any tool.
+var readOnlyArgs = []string{"--version", "version", "--help", "help"}
+
+// RegisterReadOnlySubcommands declares subcommands of the tool that do not modify anything.
+// Such subcommands are run even in dry-run mode.
+func RegisterReadOnlySubcommands(tool string, subcommands ...string) {
+	readOnlySubcommands[tool] = append(readOnlySubcommands[tool], subcommands...)
+}
+
+// IsReadOnlyCommand checks whether the command only reads data, based on its subcommand.
+// The subcommand is the first argument that is not an option.
+func IsReadOnlyCommand(command string, args []string) bool {
+	for _, arg := range args {
+		if slices.Contains(readOnlyArgs, arg) {
+			return true
+		}
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		return slices.Contains(readOnlySubcommands[command], arg)
+	}
+	return false
+}
+
+var dryRunScript *DryRunScript
+
+// EnableDryRun makes executors created by NewExecutor record modifying commands into the given output instead of running them.
+func EnableDryRun(out io.Writer) {
+	dryRunScript = NewDryRunScript(out)
+}
+
+// IsDryRun returns true if dry-run mode is enabled for the CLI run.
+func IsDryRun() bool {
+	return dryRunScript != nil
+}
+
+// DryRunScript writes recorded commands as a shell script.
+type DryRunScript struct {
+	out           io.Writer
+	headerWritten bool
+	mutex         sync.Mutex
+}
+
+func NewDryRunScript(out io.Writer) *DryRunScript {
+	return &DryRunScript{out: out}
+}
+
+// Record appends the command to the script.
+// Sensitive values are masked, so they have to be filled in manually before running the script.
+func (s *DryRunScript) Record(opts *ExecOptions, command string, args []string) error {
+	if opts == nil {
+		opts = &ExecOptions{}
+	}
+
+	var line strings.Builder
+	if opts.Stdin != nil {
+		line.WriteString("# The command below reads input provided by the CLI, which is not recorded\n")
+	}
+	if opts.Dir != "" {
+		line.WriteString("(cd " + shellQuote(opts.Dir) + " && ")
+	}
+	if len(opts.Env) != 0 || opts.ReplaceEnv {
+		line.WriteString("env ")
+		if opts.ReplaceEnv {
+			line.WriteString("-i ")
+		}
+		for _, name := range slices.Sorted(maps.Keys(opts.Env)) {
+			line.WriteString(shellQuote(name+"="+l.Redact(opts.Env[name])) + " ")
+		}
+	}
+	line.WriteString(shellQuote(command))
+	for _, arg := range RedactArgs(args) {
+		line.WriteString(" " + shellQuote(l.Redact(arg)))
+	}
+	if opts.Dir != "" {
+		line.WriteString(")")
+	}
+	line.WriteString("\n")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.headerWritten {
+		if _, err := io.WriteString(s.out, "#!/bin/sh\nset -e\n"); err != nil {
+			return fmt.Errorf("failed to write dry-run script: %w", err)
+		}
+		s.headerWritten = true
+	}
+	if _, err := io.WriteString(s.out, line.String()); err != nil {
+		return fmt.Errorf("failed to write dry-run script: %w", err)
+	}
+	return nil
+}
+
+var shellSafeRegex = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes the value for POSIX shell, if needed.
+func shellQuote(value string) string {
+	if shellSafeRegex.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// NewDryRunExecutor returns an executor which records commands into the script instead of running them.
+// Read-only commands, see IsReadOnlyCommand, are run by the given executor,
+// so callers still get real data, e.g. results of image inspection.
+// Recorded commands succeed with empty output.
+func NewDryRunExecutor(executor CliExecutorInterface, script *DryRunScript) *ChainExecutor {
+	return NewChainExecutor(executor, DryRunMiddleware(script, IsReadOnlyCommand))
+}
@@ -0,0 +1,117 @@
+package cliwrappers_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/cliwrappers"
+)
+
+func TestIsReadOnlyCommand(t *testing.T) {
+	g := NewWithT(t)
+
+	readOnlyCommands := [][]string{
+		{"skopeo", "inspect", "docker://registry.io/image:tag"},
+		{"skopeo", "--debug", "list-tags", "docker://registry.io/image"},
+		{"skopeo", "--version"},
+		{"git", "rev-parse", "HEAD"},
+		{"some-tool", "version"},
+	}
+	for _, command := range readOnlyCommands {
+		g.Expect(cliwrappers.IsReadOnlyCommand(command[0], command[1:])).To(BeTrue(), strings.Join(command, " "))
+	}
+
+	modifyingCommands := [][]string{
+		{"skopeo", "copy", "docker://registry.io/image:tag", "docker://registry.io/image:tag2"},
+		{"skopeo", "delete", "docker://registry.io/image:tag"},
+		{"git", "push"},
+		{"some-tool"},
+		{"some-tool", "inspect"},
+	}
+	for _, command := range modifyingCommands {
+		g.Expect(cliwrappers.IsReadOnlyCommand(command[0], command[1:])).To(BeFalse(), strings.Join(command, " "))
+	}
+
+	cliwrappers.RegisterReadOnlySubcommands("custom-tool", "inspect")
+	g.Expect(cliwrappers.IsReadOnlyCommand("custom-tool", []string{"inspect"})).To(BeTrue())
+}
+
+func TestDryRunExecutor(t *testing.T) {
+	setup := func() (*cliwrappers.ChainExecutor, *mockExecutor, *bytes.Buffer) {
+		executor := &mockExecutor{}
+		var script bytes.Buffer
+		dryRunExecutor := cliwrappers.NewDryRunExecutor(executor, cliwrappers.NewDryRunScript(&script))
+		return dryRunExecutor, executor, &script
+	}
+
+	t.Run("should record modifying commands as shell script", func(t *testing.T) {
+		g := NewWithT(t)
+
+		dryRunExecutor, executor, script := setup()
+		isExecuteCalled := false
+		executor.executeInDirFunc = func(workdir, command string, args ...string) (string, string, int, error) {
+			isExecuteCalled = true
+			return "", "", 0, nil
+		}
+
+		stdout, stderr, exitCode, err := dryRunExecutor.Execute("skopeo", "copy", "docker://registry.io/image@sha256:abc", "docker://registry.io/image:tag")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(exitCode).To(Equal(0))
+		g.Expect(stdout).To(BeEmpty())
+		g.Expect(stderr).To(BeEmpty())
+
+		_, _, _, err = dryRunExecutor.ExecuteWithOutput("skopeo", "copy", "--format", "{{ .Name }}", "--dest-creds", "user:pass", "docker://a", "docker://b")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(isExecuteCalled).To(BeFalse())
+		g.Expect(script.String()).To(Equal("#!/bin/sh\nset -e\n" +
+			"skopeo copy docker://registry.io/image@sha256:abc docker://registry.io/image:tag\n" +
+			"skopeo copy --format '{{ .Name }}' --dest-creds '***' docker://a docker://b\n"))
+	})
+
+	t.Run("should run read-only commands", func(t *testing.T) {
+		g := NewWithT(t)
+
+		dryRunExecutor, executor, script := setup()
+		executor.executeInDirFunc = func(workdir, command string, args ...string) (string, string, int, error) {
+			return "inspect output", "", 0, nil
+		}
+
+		stdout, _, _, err := dryRunExecutor.Execute("skopeo", "inspect", "docker://registry.io/image:tag")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(stdout).To(Equal("inspect output"))
+		g.Expect(script.String()).To(BeEmpty())
+	})
+
+	t.Run("should record working directory and environment", func(t *testing.T) {
+		g := NewWithT(t)
+
+		dryRunExecutor, _, script := setup()
+		opts := &cliwrappers.ExecOptions{
+			Dir:   "/some dir",
+			Env:   map[string]string{"B": "it's", "A": "1"},
+			Stdin: strings.NewReader("input"),
+		}
+
+		result, err := dryRunExecutor.ExecuteWithOptions(context.Background(), opts, "tool", "run")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.ExitCode).To(Equal(0))
+		g.Expect(script.String()).To(HaveSuffix("# The command below reads input provided by the CLI, which is not recorded\n" +
+			`(cd '/some dir' && env A=1 'B=it'\''s' tool run)` + "\n"))
+	})
+}
+
+func TestNewExecutor(t *testing.T) {
+	g := NewWithT(t)
+
+	executor := cliwrappers.NewExecutor(context.Background())
+	g.Expect(executor).To(BeAssignableToTypeOf(&cliwrappers.ChainExecutor{}))
+	g.Expect(executor.(*cliwrappers.ChainExecutor).Executor).To(BeAssignableToTypeOf(&cliwrappers.CliExecutor{}))
+	g.Expect(cliwrappers.IsDryRun()).To(BeFalse())
+}
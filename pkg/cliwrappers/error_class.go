@@ -0,0 +1,108 @@
+package cliwrappers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ErrorClass is a category of a tool failure, used to decide whether the failure is worth retrying.
+type ErrorClass string
+
+const (
+	ErrorClassUnknown     ErrorClass = "unknown"
+	ErrorClassAuth        ErrorClass = "auth"
+	ErrorClassNotFound    ErrorClass = "not-found"
+	ErrorClassRateLimited ErrorClass = "rate-limited"
+	ErrorClassNetwork     ErrorClass = "network"
+	ErrorClassServer      ErrorClass = "server"
+	ErrorClassClient      ErrorClass = "client"
+)
+
+// IsRetryable returns true if a failure of the class might disappear on the next attempt.
+// Unknown failures are retried, as they used to be before classification.
+func (c ErrorClass) IsRetryable() bool {
+	switch c {
+	case ErrorClassAuth, ErrorClassNotFound, ErrorClassClient:
+		return false
+	default:
+		return true
+	}
+}
+
+// ErrorClassifier determines the class of a failed tool call.
+type ErrorClassifier func(stdout, stderr string, exitCode int, err error) ErrorClass
+
+// ClassifiedError is returned by Retryer with an error classifier configured.
+// It allows callers to react on the kind of the final failure.
+type ClassifiedError struct {
+	Class ErrorClass
+	Err   error
+}
+
+func (e *ClassifiedError) Error() string {
+	return fmt.Sprintf("%s (%s error)", e.Err.Error(), e.Class)
+}
+
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorClassOf returns the class of the given error, or ErrorClassUnknown if the error wasn't classified.
+func ErrorClassOf(err error) ErrorClass {
+	var classifiedErr *ClassifiedError
+	if errors.As(err, &classifiedErr) {
+		return classifiedErr.Class
+	}
+	return ErrorClassUnknown
+}
+
+// registryErrorPatterns are checked in order, the first matching pattern determines the class.
+// For example, rate limiting must be checked before server errors,
+// because some registries report it with 5xx status codes.
+var registryErrorPatterns = []struct {
+	class ErrorClass
+	regex *regexp.Regexp
+}{
+	{ErrorClassRateLimited, regexp.MustCompile(`(?i)toomanyrequests|too many requests|rate limit|\b429\b`)},
+	{ErrorClassAuth, regexp.MustCompile(`(?i)unauthorized|authentication required|\bdenied\b|forbidden|invalid username/password|\b40[13]\b`)},
+	{ErrorClassNotFound, regexp.MustCompile(`(?i)manifest unknown|name unknown|blob unknown|not found|\b404\b`)},
+	{ErrorClassClient, regexp.MustCompile(`(?i)invalid reference format|invalid argument|invalid digest|manifest invalid|bad request|unknown flag|\b400\b`)},
+	{ErrorClassServer, regexp.MustCompile(`(?i)internal server error|bad gateway|service unavailable|gateway timeout|\b50[0234]\b`)},
+	{ErrorClassNetwork, regexp.MustCompile(`(?i)connection refused|connection reset|no such host|i/o timeout|tls handshake timeout|network is unreachable|temporary failure in name resolution|broken pipe|unexpected eof|\beof\b|timeout`)},
+}
+
+// ClassifyRegistryError classifies failures of tools working with image registries, e.g. skopeo.
+func ClassifyRegistryError(stdout, stderr string, exitCode int, err error) ErrorClass {
+	output := stderr + "\n" + stdout
+	if err != nil {
+		output += "\n" + err.Error()
+	}
+	for _, pattern := range registryErrorPatterns {
+		if pattern.regex.MatchString(output) {
+			return pattern.class
+		}
+	}
+	return ErrorClassUnknown
+}
+
+// ClassifyRegistryFailure classifies errors of in-process registry operations, see ClassifyRegistryError.
+func ClassifyRegistryFailure(err error) ErrorClass {
+	return ClassifyRegistryError("", "", -1, err)
+}
+
+var retryAfterRegex = regexp.MustCompile(`(?i)retry[- ]after:?\s*(\d+)`)
+
+// parseRetryAfter returns the wait time requested by a rate limiting server, if the tool printed it.
+func parseRetryAfter(stdout, stderr string) time.Duration {
+	for _, output := range []string{stderr, stdout} {
+		if match := retryAfterRegex.FindStringSubmatch(output); match != nil {
+			if seconds, err := strconv.Atoi(match[1]); err == nil {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+package cliwrappers_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/cliwrappers"
+)
+
+func TestClassifyRegistryError(t *testing.T) {
+	g := NewWithT(t)
+
+	testCases := []struct {
+		stderr        string
+		expectedClass cliwrappers.ErrorClass
+	}{
+		{`time="..." level=fatal msg="Error parsing image name \"docker://quay.io/org/image:tag\": reading manifest tag in quay.io/org/image: unauthorized: access to the requested resource is not authorized"`, cliwrappers.ErrorClassAuth},
+		{`reading manifest tag in quay.io/org/image: denied: requested access to the resource is denied`, cliwrappers.ErrorClassAuth},
+		{`initializing source docker://quay.io/org/image:tag: reading manifest tag in quay.io/org/image: manifest unknown`, cliwrappers.ErrorClassNotFound},
+		{`reading manifest latest in registry.io/org/image: name unknown: repository name not known to registry`, cliwrappers.ErrorClassNotFound},
+		{`invalid reference format`, cliwrappers.ErrorClassClient},
+		{`writing manifest: uploading manifest tag to quay.io/org/image: toomanyrequests: too many requests`, cliwrappers.ErrorClassRateLimited},
+		{`received unexpected HTTP status: 429 Too Many Requests`, cliwrappers.ErrorClassRateLimited},
+		{`received unexpected HTTP status: 502 Bad Gateway`, cliwrappers.ErrorClassServer},
+		{`received unexpected HTTP status: 500 Internal Server Error`, cliwrappers.ErrorClassServer},
+		{`pinging container registry quay.io: Get "https://quay.io/v2/": dial tcp: lookup quay.io: no such host`, cliwrappers.ErrorClassNetwork},
+		{`Get "https://quay.io/v2/": net/http: TLS handshake timeout`, cliwrappers.ErrorClassNetwork},
+		{`read tcp 10.0.0.1:5000->10.0.0.2:443: read: connection reset by peer`, cliwrappers.ErrorClassNetwork},
+		{`something completely unexpected happened`, cliwrappers.ErrorClassUnknown},
+	}
+	for _, tc := range testCases {
+		class := cliwrappers.ClassifyRegistryError("", tc.stderr, 1, errors.New("exit status 1"))
+		g.Expect(class).To(Equal(tc.expectedClass), tc.stderr)
+	}
+}
+
+func TestErrorClass_IsRetryable(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(cliwrappers.ErrorClassAuth.IsRetryable()).To(BeFalse())
+	g.Expect(cliwrappers.ErrorClassNotFound.IsRetryable()).To(BeFalse())
+	g.Expect(cliwrappers.ErrorClassClient.IsRetryable()).To(BeFalse())
+	g.Expect(cliwrappers.ErrorClassRateLimited.IsRetryable()).To(BeTrue())
+	g.Expect(cliwrappers.ErrorClassNetwork.IsRetryable()).To(BeTrue())
+	g.Expect(cliwrappers.ErrorClassServer.IsRetryable()).To(BeTrue())
+	g.Expect(cliwrappers.ErrorClassUnknown.IsRetryable()).To(BeTrue())
+}
+
+func TestErrorClassOf(t *testing.T) {
+	g := NewWithT(t)
+
+	originalErr := errors.New("exit status 1")
+	classifiedErr := &cliwrappers.ClassifiedError{Class: cliwrappers.ErrorClassNotFound, Err: originalErr}
+	wrappedErr := fmt.Errorf("failed to copy: %w", classifiedErr)
+
+	g.Expect(cliwrappers.ErrorClassOf(wrappedErr)).To(Equal(cliwrappers.ErrorClassNotFound))
+	g.Expect(cliwrappers.ErrorClassOf(originalErr)).To(Equal(cliwrappers.ErrorClassUnknown))
+	g.Expect(errors.Is(wrappedErr, originalErr)).To(BeTrue())
+	g.Expect(classifiedErr.Error()).To(Equal("exit status 1 (not-found error)"))
+}
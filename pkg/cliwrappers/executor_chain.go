@@ -0,0 +1,145 @@
+package cliwrappers
+
+import (
+	"context"
+	"time"
+)
+
+// ExecuteFunc runs a command, it's the unit of work decorated by middlewares.
+type ExecuteFunc func(ctx context.Context, opts *ExecOptions, command string, args ...string) (*ExecResult, error)
+
+// Middleware decorates command execution with a cross-cutting behavior, e.g. logging or dry-run.
+// A middleware calls next to continue the execution or returns its own result to short-circuit it.
+type Middleware func(next ExecuteFunc) ExecuteFunc
+
+var _ CliExecutorInterface = &ChainExecutor{}
+var _ CliExecutorContextInterface = &ChainExecutor{}
+var _ CliExecutorWithOptionsInterface = &ChainExecutor{}
+
+// ChainExecutor runs commands through a chain of middlewares ending with the wrapped executor.
+// The first middleware is the outermost one, i.e. it sees the call first and the result last.
+type ChainExecutor struct {
+	Executor CliExecutorInterface
+
+	execute ExecuteFunc
+}
+
+func NewChainExecutor(executor CliExecutorInterface, middlewares ...Middleware) *ChainExecutor {
+	execute := ExecuteFunc(func(ctx context.Context, opts *ExecOptions, command string, args ...string) (*ExecResult, error) {
+		return ExecuteWith(ctx, executor, opts, command, args...)
+	})
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		execute = middlewares[i](execute)
+	}
+	return &ChainExecutor{
+		Executor: executor,
+		execute:  execute,
+	}
+}
+
+// Context returns the context of the wrapped executor.
+func (c *ChainExecutor) Context() context.Context {
+	return executorContext(c.Executor)
+}
+
+func (c *ChainExecutor) Execute(command string, args ...string) (string, string, int, error) {
+	return c.ExecuteInDirContext(c.Context(), "", command, args...)
+}
+
+func (c *ChainExecutor) ExecuteContext(ctx context.Context, command string, args ...string) (string, string, int, error) {
+	return c.ExecuteInDirContext(ctx, "", command, args...)
+}
+
+func (c *ChainExecutor) ExecuteInDir(workdir, command string, args ...string) (string, string, int, error) {
+	return c.ExecuteInDirContext(c.Context(), workdir, command, args...)
+}
+
+func (c *ChainExecutor) ExecuteInDirContext(ctx context.Context, workdir, command string, args ...string) (string, string, int, error) {
+	return unpackExecResult(c.ExecuteWithOptions(ctx, &ExecOptions{Dir: workdir}, command, args...))
+}
+
+func (c *ChainExecutor) ExecuteWithOutput(command string, args ...string) (string, string, int, error) {
+	return c.ExecuteInDirWithOutputContext(c.Context(), "", command, args...)
+}
+
+func (c *ChainExecutor) ExecuteWithOutputContext(ctx context.Context, command string, args ...string) (string, string, int, error) {
+	return c.ExecuteInDirWithOutputContext(ctx, "", command, args...)
+}
+
+func (c *ChainExecutor) ExecuteInDirWithOutput(workdir, command string, args ...string) (string, string, int, error) {
+	return c.ExecuteInDirWithOutputContext(c.Context(), workdir, command, args...)
+}
+
+func (c *ChainExecutor) ExecuteInDirWithOutputContext(ctx context.Context, workdir, command string, args ...string) (string, string, int, error) {
+	return unpackExecResult(c.ExecuteWithOptions(ctx, &ExecOptions{Dir: workdir, PrintOutput: true}, command, args...))
+}
+
+// ExecuteWithOptions runs the command through the middlewares.
+func (c *ChainExecutor) ExecuteWithOptions(ctx context.Context, opts *ExecOptions, command string, args ...string) (*ExecResult, error) {
+	if ctx == nil {
+		ctx = c.Context()
+	}
+	if opts == nil {
+		opts = &ExecOptions{}
+	}
+	return c.execute(ctx, opts, command, args...)
+}
+
+// LoggingMiddleware logs each command with sensitive arguments masked, and its output.
+// Output printed in real time, see ExecOptions.PrintOutput, is not logged again.
+func LoggingMiddleware() Middleware {
+	return func(next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, opts *ExecOptions, command string, args ...string) (*ExecResult, error) {
+			executorLog.Debugf("Running command:\n%s", FormatCommand(command, args))
+			result, err := next(ctx, opts, command, args...)
+			if err != nil {
+				executorLog.Debugf("Command %s failed with exit code %d: %s", command, result.ExitCode, err.Error())
+			}
+			if !opts.PrintOutput {
+				executorLog.Debugf("%s [stdout]:\n%s", command, result.Stdout)
+				executorLog.Debugf("%s [stderr]:\n%s", command, result.Stderr)
+			}
+			return result, err
+		}
+	}
+}
+
+// TimingMiddleware logs how long each command took.
+func TimingMiddleware() Middleware {
+	return func(next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, opts *ExecOptions, command string, args ...string) (*ExecResult, error) {
+			start := time.Now()
+			result, err := next(ctx, opts, command, args...)
+			executorLog.Debugf("Command %s took %v", command, time.Since(start).Round(time.Millisecond))
+			return result, err
+		}
+	}
+}
+
+// DryRunMiddleware records commands into the script instead of running them.
+// Commands for which isReadOnly returns true are run.
+func DryRunMiddleware(script *DryRunScript, isReadOnly func(command string, args []string) bool) Middleware {
+	return func(next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, opts *ExecOptions, command string, args ...string) (*ExecResult, error) {
+			if isReadOnly != nil && isReadOnly(command, args) {
+				dryRunLog.Debugf("Running read-only command: %s", FormatCommand(command, args))
+				return next(ctx, opts, command, args...)
+			}
+
+			dryRunLog.Infof("Skipping command: %s", FormatCommand(command, args))
+			if err := script.Record(opts, command, args); err != nil {
+				return &ExecResult{ExitCode: -1}, err
+			}
+			return &ExecResult{}, nil
+		}
+	}
+}
+
+// executorMiddlewares returns middlewares configured for the current CLI run.
+func executorMiddlewares() []Middleware {
+	middlewares := []Middleware{LoggingMiddleware(), TimingMiddleware()}
+	if dryRunScript != nil {
+		middlewares = append(middlewares, DryRunMiddleware(dryRunScript, IsReadOnlyCommand))
+	}
+	return middlewares
+}
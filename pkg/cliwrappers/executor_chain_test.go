@@ -0,0 +1,138 @@
+package cliwrappers_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/cliwrappers"
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+func TestChainExecutor(t *testing.T) {
+	// tracingMiddleware records the order in which middlewares see the call and the result.
+	tracingMiddleware := func(name string, trace *[]string) cliwrappers.Middleware {
+		return func(next cliwrappers.ExecuteFunc) cliwrappers.ExecuteFunc {
+			return func(ctx context.Context, opts *cliwrappers.ExecOptions, command string, args ...string) (*cliwrappers.ExecResult, error) {
+				*trace = append(*trace, name+" before")
+				result, err := next(ctx, opts, command, args...)
+				*trace = append(*trace, name+" after")
+				return result, err
+			}
+		}
+	}
+
+	t.Run("should run command through middlewares in order", func(t *testing.T) {
+		g := NewWithT(t)
+		var trace []string
+		executor := &mockExecutor{
+			executeInDirFunc: func(workdir, command string, args ...string) (string, string, int, error) {
+				trace = append(trace, "execute "+command)
+				return "output", "", 0, nil
+			},
+		}
+		chain := cliwrappers.NewChainExecutor(executor, tracingMiddleware("first", &trace), tracingMiddleware("second", &trace))
+
+		stdout, _, exitCode, err := chain.Execute("tool", "arg")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(stdout).To(Equal("output"))
+		g.Expect(exitCode).To(Equal(0))
+		g.Expect(trace).To(Equal([]string{"first before", "second before", "execute tool", "second after", "first after"}))
+	})
+
+	t.Run("should allow middleware to short-circuit execution", func(t *testing.T) {
+		g := NewWithT(t)
+		isExecuteCalled := false
+		executor := &mockExecutor{
+			executeInDirFunc: func(workdir, command string, args ...string) (string, string, int, error) {
+				isExecuteCalled = true
+				return "", "", 0, nil
+			},
+		}
+		cached := func(next cliwrappers.ExecuteFunc) cliwrappers.ExecuteFunc {
+			return func(ctx context.Context, opts *cliwrappers.ExecOptions, command string, args ...string) (*cliwrappers.ExecResult, error) {
+				return &cliwrappers.ExecResult{Stdout: "cached"}, nil
+			}
+		}
+		chain := cliwrappers.NewChainExecutor(executor, cached)
+
+		stdout, _, _, err := chain.ExecuteInDir("/tmp", "tool")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(stdout).To(Equal("cached"))
+		g.Expect(isExecuteCalled).To(BeFalse())
+	})
+
+	t.Run("should pass options and context of the wrapped executor", func(t *testing.T) {
+		g := NewWithT(t)
+		type ctxKey struct{}
+		ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+		var seenOpts *cliwrappers.ExecOptions
+		var seenCtx context.Context
+		inspect := func(next cliwrappers.ExecuteFunc) cliwrappers.ExecuteFunc {
+			return func(ctx context.Context, opts *cliwrappers.ExecOptions, command string, args ...string) (*cliwrappers.ExecResult, error) {
+				seenOpts, seenCtx = opts, ctx
+				return next(ctx, opts, command, args...)
+			}
+		}
+		chain := cliwrappers.NewChainExecutor(cliwrappers.NewCliExecutor().WithContext(ctx), inspect)
+
+		_, _, _, err := chain.ExecuteInDirWithOutput("/", "true")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(seenOpts.Dir).To(Equal("/"))
+		g.Expect(seenOpts.PrintOutput).To(BeTrue())
+		g.Expect(seenCtx.Value(ctxKey{})).To(Equal("value"))
+		g.Expect(chain.Context()).To(Equal(ctx))
+	})
+
+	t.Run("should record commands with dry-run middleware", func(t *testing.T) {
+		g := NewWithT(t)
+		var script bytes.Buffer
+		executor := &mockExecutor{
+			executeInDirFunc: func(workdir, command string, args ...string) (string, string, int, error) {
+				return "image data", "", 0, nil
+			},
+		}
+		chain := cliwrappers.NewChainExecutor(executor,
+			cliwrappers.DryRunMiddleware(cliwrappers.NewDryRunScript(&script), cliwrappers.IsReadOnlyCommand))
+
+		stdout, _, _, err := chain.Execute("skopeo", "inspect", "docker://registry.io/image")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(stdout).To(Equal("image data"))
+
+		stdout, _, _, err = chain.Execute("skopeo", "copy", "docker://a", "docker://b")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(stdout).To(BeEmpty())
+		g.Expect(script.String()).To(HaveSuffix("skopeo copy docker://a docker://b\n"))
+	})
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	g := NewWithT(t)
+	var logs bytes.Buffer
+	l.Logger.SetOutput(&logs)
+	l.Logger.SetLevel(logrus.DebugLevel)
+	defer l.Logger.SetOutput(os.Stderr)
+	defer l.Logger.SetLevel(logrus.InfoLevel)
+
+	executor := &mockExecutor{
+		executeInDirFunc: func(workdir, command string, args ...string) (string, string, int, error) {
+			return "tool output", "", 0, nil
+		},
+	}
+	chain := cliwrappers.NewChainExecutor(executor, cliwrappers.LoggingMiddleware(), cliwrappers.TimingMiddleware())
+
+	_, _, _, err := chain.Execute("skopeo", "copy", "--dest-creds", "user:secret-password", "docker://a", "docker://b")
+
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(logs.String()).To(ContainSubstring("skopeo copy --dest-creds *** docker://a docker://b"))
+	g.Expect(logs.String()).To(ContainSubstring("tool output"))
+	g.Expect(logs.String()).To(ContainSubstring("Command skopeo took"))
+	g.Expect(logs.String()).ToNot(ContainSubstring("secret-password"))
+}
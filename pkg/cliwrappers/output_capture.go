@@ -0,0 +1,128 @@
+package cliwrappers
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+const (
+	// DefaultMaxOutputInMemory is the size of a command output kept in memory before it's spilled to a file.
+	DefaultMaxOutputInMemory = 16 * 1024 * 1024
+	// DefaultOutputTailSize is the size of the output end kept in memory after the output was spilled to a file.
+	DefaultOutputTailSize = 64 * 1024
+	// DefaultMaxLogLineLength is the length of printed output lines, longer lines are truncated.
+	DefaultMaxLogLineLength = 64 * 1024
+)
+
+// outputCapture keeps command output in memory up to maxInMemory bytes.
+// Bigger output is written into a temporary file and only its last tailSize bytes are kept in memory.
+type outputCapture struct {
+	name        string
+	maxInMemory int
+	tailSize    int
+
+	buf  bytes.Buffer
+	file *os.File
+	size int64
+	tail []byte
+	// spillErr is set if the output could not be written into the file, then only the tail is kept.
+	spillErr error
+}
+
+func newOutputCapture(name string, maxInMemory, tailSize int) *outputCapture {
+	return &outputCapture{name: name, maxInMemory: maxInMemory, tailSize: tailSize}
+}
+
+func (c *outputCapture) Write(p []byte) (int, error) {
+	c.size += int64(len(p))
+	if !c.isSpilled() && (c.maxInMemory < 0 || c.buf.Len()+len(p) <= c.maxInMemory) {
+		return c.buf.Write(p)
+	}
+
+	if !c.isSpilled() {
+		c.spill()
+	}
+	if c.file != nil {
+		if _, err := c.file.Write(p); err != nil {
+			executorLog.Warnf("Failed to write %s into '%s', keeping only its end: %s", c.name, c.file.Name(), err.Error())
+			c.spillErr = err
+			c.file.Close()
+			c.file = nil
+		}
+	}
+	c.appendTail(p)
+	// Never fail the command because of the capture.
+	return len(p), nil
+}
+
+func (c *outputCapture) isSpilled() bool {
+	return c.file != nil || c.spillErr != nil
+}
+
+// spill moves the output collected so far into a temporary file.
+func (c *outputCapture) spill() {
+	file, err := os.CreateTemp("", "kbc-output-*")
+	if err == nil {
+		_, err = file.Write(c.buf.Bytes())
+		if err != nil {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}
+	if err != nil {
+		executorLog.Warnf("Failed to spill big %s into a file, keeping only its end: %s", c.name, err.Error())
+		c.spillErr = err
+	} else {
+		executorLog.Debugf("Output %s exceeded %d bytes, writing it into '%s'", c.name, c.maxInMemory, file.Name())
+		c.file = file
+	}
+	c.appendTail(c.buf.Bytes())
+	c.buf = bytes.Buffer{}
+}
+
+func (c *outputCapture) appendTail(p []byte) {
+	if len(p) >= c.tailSize {
+		c.tail = append(c.tail[:0], p[len(p)-c.tailSize:]...)
+		return
+	}
+	if overflow := len(c.tail) + len(p) - c.tailSize; overflow > 0 {
+		c.tail = append(c.tail[:0], c.tail[overflow:]...)
+	}
+	c.tail = append(c.tail, p...)
+}
+
+// Close closes the spill file, if any.
+func (c *outputCapture) Close() {
+	if c.file != nil {
+		c.file.Close()
+	}
+}
+
+// Path returns the file with the full output, empty if the output was kept in memory.
+func (c *outputCapture) Path() string {
+	if c.file == nil {
+		return ""
+	}
+	return c.file.Name()
+}
+
+// String returns the whole output if it was kept in memory.
+// Otherwise, it returns the end of the output with a note where the full output is.
+func (c *outputCapture) String() string {
+	if !c.isSpilled() {
+		return c.buf.String()
+	}
+
+	tail := c.tail
+	// Start with a complete line, if possible.
+	if i := bytes.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+		tail = tail[i+1:]
+	}
+	truncated := c.size - int64(len(tail))
+	note := fmt.Sprintf("[%d bytes of %s truncated, full output in %s]\n", truncated, c.name, c.Path())
+	if c.file == nil {
+		note = fmt.Sprintf("[%d bytes of %s truncated]\n", truncated, c.name)
+	}
+	return note + string(tail)
+}
@@ -0,0 +1,91 @@
+package cliwrappers
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+var (
+	sensitiveArgsMutex sync.RWMutex
+	// sensitiveArgs lists CLI options which values must never be logged.
+	sensitiveArgs = []string{
+		"--creds",
+		"--src-creds",
+		"--dest-creds",
+		"--password",
+		"--registry-token",
+		"--src-registry-token",
+		"--dest-registry-token",
+	}
+)
+
+// AddSensitiveArg registers an additional CLI option which value must not be logged.
+func AddSensitiveArg(argName string) {
+	sensitiveArgsMutex.Lock()
+	defer sensitiveArgsMutex.Unlock()
+	if !slices.Contains(sensitiveArgs, argName) {
+		sensitiveArgs = append(sensitiveArgs, argName)
+	}
+}
+
+// RemoveSensitiveArg unregisters a CLI option previously added with AddSensitiveArg.
+func RemoveSensitiveArg(argName string) {
+	sensitiveArgsMutex.Lock()
+	defer sensitiveArgsMutex.Unlock()
+	sensitiveArgs = slices.DeleteFunc(sensitiveArgs, func(arg string) bool { return arg == argName })
+}
+
+func isSensitiveArg(arg string) bool {
+	sensitiveArgsMutex.RLock()
+	defer sensitiveArgsMutex.RUnlock()
+	return slices.Contains(sensitiveArgs, arg)
+}
+
+// RedactArgs returns a copy of the given command arguments with values of sensitive options masked.
+// Both "--creds value" and "--creds=value" forms are handled.
+func RedactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if name, _, found := strings.Cut(arg, "="); found && isSensitiveArg(name) {
+			redacted[i] = name + "=" + l.RedactedValue
+			continue
+		}
+		redacted[i] = arg
+		if isSensitiveArg(arg) && i+1 < len(args) {
+			i++
+			redacted[i] = l.RedactedValue
+		}
+	}
+	return redacted
+}
+
+// registerArgSecrets registers values of sensitive options as secrets,
+// so they are masked also when a tool prints them into its output.
+func registerArgSecrets(args []string) {
+	for i, arg := range args {
+		if name, value, found := strings.Cut(arg, "="); found && isSensitiveArg(name) {
+			registerCredentialsSecret(value)
+			continue
+		}
+		if isSensitiveArg(arg) && i+1 < len(args) {
+			registerCredentialsSecret(args[i+1])
+		}
+	}
+}
+
+// registerCredentialsSecret registers the whole value and, for user:password form, the password alone.
+func registerCredentialsSecret(value string) {
+	l.AddSecret(value)
+	if _, password, found := strings.Cut(value, ":"); found {
+		l.AddSecret(password)
+	}
+}
+
+// FormatCommand returns the command line with sensitive data masked, suitable for logging.
+func FormatCommand(command string, args []string) string {
+	return l.Redact(strings.TrimSpace(command + " " + strings.Join(RedactArgs(args), " ")))
+}
@@ -0,0 +1,69 @@
+package cliwrappers_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/cliwrappers"
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+func TestRedactArgs(t *testing.T) {
+	t.Run("should mask values of sensitive options", func(t *testing.T) {
+		g := NewWithT(t)
+
+		args := []string{"copy", "--src-creds", "user:pass", "--dest-creds=user2:pass2", "--retry-times", "3", "docker://a", "docker://b"}
+
+		redacted := cliwrappers.RedactArgs(args)
+
+		g.Expect(redacted).To(Equal([]string{"copy", "--src-creds", "***", "--dest-creds=***", "--retry-times", "3", "docker://a", "docker://b"}))
+		// The original args must not be modified
+		g.Expect(args[2]).To(Equal("user:pass"))
+	})
+
+	t.Run("should handle sensitive option without value", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(cliwrappers.RedactArgs([]string{"login", "--password"})).To(Equal([]string{"login", "--password"}))
+	})
+
+	t.Run("should mask custom sensitive options", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cliwrappers.AddSensitiveArg("--my-token")
+		t.Cleanup(func() { cliwrappers.RemoveSensitiveArg("--my-token") })
+
+		g.Expect(cliwrappers.RedactArgs([]string{"--my-token", "abc", "--other", "def"})).To(Equal([]string{"--my-token", "***", "--other", "def"}))
+	})
+}
+
+func TestFormatCommand(t *testing.T) {
+	t.Run("should format command with masked credentials", func(t *testing.T) {
+		g := NewWithT(t)
+
+		formatted := cliwrappers.FormatCommand("skopeo", []string{"inspect", "--creds", "user:pass", "docker://registry.io/image"})
+
+		g.Expect(formatted).To(Equal("skopeo inspect --creds *** docker://registry.io/image"))
+	})
+}
+
+func TestCliExecutor_RedactsOutput(t *testing.T) {
+	t.Run("should register credentials passed via args as secrets", func(t *testing.T) {
+		g := NewWithT(t)
+
+		t.Cleanup(func() {
+			l.RemoveSecret("someuser:supersecretpassword")
+			l.RemoveSecret("supersecretpassword")
+		})
+		executor := cliwrappers.NewCliExecutor()
+
+		stdout, _, _, err := executor.Execute("echo", "--creds", "someuser:supersecretpassword")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		// Output itself is returned unchanged, but logging it must not reveal the password
+		g.Expect(stdout).To(ContainSubstring("supersecretpassword"))
+		g.Expect(l.Redact(stdout)).ToNot(ContainSubstring("supersecretpassword"))
+		g.Expect(l.Redact("password is supersecretpassword")).To(Equal("password is ***"))
+	})
+}
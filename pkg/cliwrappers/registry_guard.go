@@ -0,0 +1,264 @@
+package cliwrappers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/containers/image/v5/docker/reference"
+
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+var registryGuardLog = l.Logger.WithField("logger", "RegistryGuard")
+
+// ErrRegistryUnavailable is matched by errors of calls rejected by an open circuit breaker.
+var ErrRegistryUnavailable = errors.New("registry is unavailable")
+
+// RegistryUnavailableError is returned instead of calling a registry which is clearly failing.
+type RegistryUnavailableError struct {
+	Host string
+	// Failures is the number of consecutive failures which opened the circuit.
+	Failures int
+	// RetryAt is the time after which the registry will be tried again.
+	RetryAt time.Time
+}
+
+func (e *RegistryUnavailableError) Error() string {
+	return fmt.Sprintf("registry %s is unavailable after %d consecutive failures, not calling it until %s",
+		e.Host, e.Failures, e.RetryAt.Format(time.TimeOnly))
+}
+
+func (e *RegistryUnavailableError) Is(target error) bool {
+	return target == ErrRegistryUnavailable
+}
+
+// RegistryGuard is a per-host circuit breaker and concurrency limiter for registry operations.
+// After FailureThreshold consecutive failed operations caused by an unavailable registry (network or server errors),
+// calls to the host fail fast with RegistryUnavailableError for OpenDuration.
+// Then a single probe call is let through: its success closes the circuit, its failure opens it again.
+// Any response from the registry, e.g. authentication error, means the registry is available and resets the failures.
+// An operation is guarded as a whole, including its retries, so the circuit makes subsequent operations fail fast
+// without cutting retries of the running ones short.
+// At most MaxConcurrent operations run against the same host at a time, if MaxConcurrent is positive.
+type RegistryGuard struct {
+	FailureThreshold int
+	OpenDuration     time.Duration
+	MaxConcurrent    int
+	// Clock measures the open circuit time, RealClock if nil.
+	Clock Clock
+
+	mutex sync.Mutex
+	hosts map[string]*hostState
+}
+
+type hostState struct {
+	consecutiveFailures int
+	openUntil           time.Time
+	probing             bool
+	slots               chan struct{}
+}
+
+func (s *hostState) isOpen() bool {
+	return !s.openUntil.IsZero()
+}
+
+func NewRegistryGuard() *RegistryGuard {
+	return &RegistryGuard{
+		FailureThreshold: 5,
+		OpenDuration:     1 * time.Minute,
+		MaxConcurrent:    4,
+	}
+}
+
+// DefaultRegistryGuard is shared by all registry operations of the process.
+var DefaultRegistryGuard = NewRegistryGuard()
+
+// HostLease holds concurrency slots of guarded hosts until released.
+type HostLease struct {
+	guard    *RegistryGuard
+	hosts    []string
+	released bool
+}
+
+// Release frees the concurrency slots and records the result of the operation for all the hosts.
+// Errors which are not classified, see ClassifiedError, are classified by ClassifyRegistryFailure.
+func (l *HostLease) Release(err error) {
+	l.ReleaseFailedHost("", err)
+}
+
+// ReleaseFailedHost frees the concurrency slots and records the result of a multi-host operation.
+// A failure is recorded only for failedHost, since it says nothing about the other hosts,
+// e.g. a failed push to a destination registry doesn't mean the source registry is down.
+// If failedHost is empty, the failure is recorded for all the hosts. Success is always recorded for all the hosts.
+func (l *HostLease) ReleaseFailedHost(failedHost string, err error) {
+	if l == nil || l.released {
+		return
+	}
+	l.released = true
+	for _, host := range l.hosts {
+		if err == nil || failedHost == "" || host == failedHost {
+			l.guard.record(host, err)
+		} else {
+			l.guard.cancelProbe(host)
+		}
+		l.guard.releaseSlot(host)
+	}
+}
+
+// Acquire checks the circuit breakers of the given hosts and waits for free concurrency slots.
+// Empty hosts are ignored. Returns RegistryUnavailableError if any of the hosts is failing.
+// The returned lease must be released after the operation.
+func (g *RegistryGuard) Acquire(ctx context.Context, hosts ...string) (*HostLease, error) {
+	// Sorted order of hosts prevents deadlocks of concurrent multi-host operations.
+	hosts = slices.DeleteFunc(slices.Clone(hosts), func(host string) bool { return host == "" })
+	slices.Sort(hosts)
+	hosts = slices.Compact(hosts)
+
+	lease := &HostLease{guard: g}
+	for _, host := range hosts {
+		if err := g.allow(host); err != nil {
+			lease.abandon()
+			return nil, err
+		}
+		if err := g.acquireSlot(ctx, host); err != nil {
+			g.cancelProbe(host)
+			lease.abandon()
+			return nil, err
+		}
+		lease.hosts = append(lease.hosts, host)
+	}
+	return lease, nil
+}
+
+// abandon frees already acquired slots without recording any result.
+func (l *HostLease) abandon() {
+	l.released = true
+	for _, host := range l.hosts {
+		l.guard.cancelProbe(host)
+		l.guard.releaseSlot(host)
+	}
+}
+
+func (g *RegistryGuard) host(host string) *hostState {
+	if g.hosts == nil {
+		g.hosts = make(map[string]*hostState)
+	}
+	state, ok := g.hosts[host]
+	if !ok {
+		state = &hostState{}
+		if g.MaxConcurrent > 0 {
+			state.slots = make(chan struct{}, g.MaxConcurrent)
+		}
+		g.hosts[host] = state
+	}
+	return state
+}
+
+func (g *RegistryGuard) now() time.Time {
+	if g.Clock == nil {
+		return RealClock.Now()
+	}
+	return g.Clock.Now()
+}
+
+// allow returns error if the circuit of the host is open.
+func (g *RegistryGuard) allow(host string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	state := g.host(host)
+	if !state.isOpen() {
+		return nil
+	}
+	if state.probing || g.now().Before(state.openUntil) {
+		return &RegistryUnavailableError{Host: host, Failures: state.consecutiveFailures, RetryAt: state.openUntil}
+	}
+	registryGuardLog.Infof("Probing registry %s", host)
+	state.probing = true
+	return nil
+}
+
+// cancelProbe lets another call probe the host, if the probing call didn't run.
+func (g *RegistryGuard) cancelProbe(host string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.host(host).probing = false
+}
+
+func (g *RegistryGuard) record(host string, err error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	state := g.host(host)
+	state.probing = false
+	if err == nil || !isRegistryUnavailableClass(registryFailureClass(err)) {
+		if state.isOpen() {
+			registryGuardLog.Infof("Registry %s is available again", host)
+		}
+		state.consecutiveFailures = 0
+		state.openUntil = time.Time{}
+		return
+	}
+
+	state.consecutiveFailures++
+	if state.isOpen() || state.consecutiveFailures >= g.FailureThreshold {
+		state.openUntil = g.now().Add(g.OpenDuration)
+		registryGuardLog.Warnf("Registry %s failed %d times in a row, not calling it for %v", host, state.consecutiveFailures, g.OpenDuration)
+	}
+}
+
+func (g *RegistryGuard) acquireSlot(ctx context.Context, host string) error {
+	g.mutex.Lock()
+	slots := g.host(host).slots
+	g.mutex.Unlock()
+	if slots == nil {
+		return nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return nil
+	default:
+	}
+	registryGuardLog.Debugf("Waiting for a free slot of registry %s", host)
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *RegistryGuard) releaseSlot(host string) {
+	g.mutex.Lock()
+	slots := g.host(host).slots
+	g.mutex.Unlock()
+	if slots != nil {
+		<-slots
+	}
+}
+
+func registryFailureClass(err error) ErrorClass {
+	if class := ErrorClassOf(err); class != ErrorClassUnknown {
+		return class
+	}
+	return ClassifyRegistryFailure(err)
+}
+
+// isRegistryUnavailableClass returns true for failures which indicate that the registry is down.
+func isRegistryUnavailableClass(class ErrorClass) bool {
+	return class == ErrorClassNetwork || class == ErrorClassServer
+}
+
+// RegistryHost returns registry host of the given image reference, e.g. quay.io for quay.io/org/image:tag.
+// Returns empty string if the reference cannot be parsed.
+func RegistryHost(imageRef string) string {
+	named, err := reference.ParseNormalizedNamed(imageRef)
+	if err != nil {
+		return ""
+	}
+	return reference.Domain(named)
+}
@@ -0,0 +1,161 @@
+package cliwrappers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/cliwrappers"
+)
+
+func TestRegistryGuard_CircuitBreaker(t *testing.T) {
+	const host = "registry.io"
+	networkErr := &cliwrappers.ClassifiedError{Class: cliwrappers.ErrorClassNetwork, Err: errors.New("connection refused")}
+	authErr := &cliwrappers.ClassifiedError{Class: cliwrappers.ErrorClassAuth, Err: errors.New("unauthorized")}
+
+	setup := func() (*cliwrappers.RegistryGuard, *cliwrappers.FakeClock) {
+		clock := cliwrappers.NewFakeClock(time.Now())
+		guard := cliwrappers.NewRegistryGuard()
+		guard.FailureThreshold = 3
+		guard.OpenDuration = time.Minute
+		guard.Clock = clock
+		return guard, clock
+	}
+	callWithResult := func(g *WithT, guard *cliwrappers.RegistryGuard, result error) {
+		lease, err := guard.Acquire(context.Background(), host)
+		g.Expect(err).ToNot(HaveOccurred())
+		lease.Release(result)
+	}
+
+	t.Run("should fail fast after consecutive failures", func(t *testing.T) {
+		g := NewWithT(t)
+		guard, _ := setup()
+
+		for i := 0; i < 3; i++ {
+			callWithResult(g, guard, networkErr)
+		}
+
+		_, err := guard.Acquire(context.Background(), host)
+		g.Expect(err).To(MatchError(cliwrappers.ErrRegistryUnavailable))
+		g.Expect(err.Error()).To(ContainSubstring("registry registry.io is unavailable after 3 consecutive failures"))
+
+		// Other hosts are not affected
+		lease, err := guard.Acquire(context.Background(), "quay.io")
+		g.Expect(err).ToNot(HaveOccurred())
+		lease.Release(nil)
+	})
+
+	t.Run("should record failure of multi-host operation only for the failed host", func(t *testing.T) {
+		g := NewWithT(t)
+		guard, _ := setup()
+
+		for i := 0; i < 3; i++ {
+			lease, err := guard.Acquire(context.Background(), "quay.io", host)
+			g.Expect(err).ToNot(HaveOccurred())
+			lease.ReleaseFailedHost(host, networkErr)
+		}
+
+		_, err := guard.Acquire(context.Background(), host)
+		g.Expect(err).To(MatchError(cliwrappers.ErrRegistryUnavailable))
+		lease, err := guard.Acquire(context.Background(), "quay.io")
+		g.Expect(err).ToNot(HaveOccurred())
+		lease.Release(nil)
+	})
+
+	t.Run("should reset failures when registry responds", func(t *testing.T) {
+		g := NewWithT(t)
+		guard, _ := setup()
+
+		callWithResult(g, guard, networkErr)
+		callWithResult(g, guard, networkErr)
+		callWithResult(g, guard, authErr)
+		callWithResult(g, guard, networkErr)
+		callWithResult(g, guard, networkErr)
+
+		lease, err := guard.Acquire(context.Background(), host)
+		g.Expect(err).ToNot(HaveOccurred())
+		lease.Release(nil)
+	})
+
+	t.Run("should let single probe through after open duration", func(t *testing.T) {
+		g := NewWithT(t)
+		guard, clock := setup()
+		for i := 0; i < 3; i++ {
+			callWithResult(g, guard, networkErr)
+		}
+		clock.Sleep(context.Background(), time.Minute)
+
+		probe, err := guard.Acquire(context.Background(), host)
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = guard.Acquire(context.Background(), host)
+		g.Expect(err).To(MatchError(cliwrappers.ErrRegistryUnavailable))
+
+		// Failed probe opens the circuit again
+		probe.Release(networkErr)
+		_, err = guard.Acquire(context.Background(), host)
+		g.Expect(err).To(MatchError(cliwrappers.ErrRegistryUnavailable))
+
+		// Successful probe closes the circuit
+		clock.Sleep(context.Background(), time.Minute)
+		callWithResult(g, guard, nil)
+		callWithResult(g, guard, nil)
+	})
+}
+
+func TestRegistryGuard_ConcurrencyLimit(t *testing.T) {
+	t.Run("should wait for a free slot of the host", func(t *testing.T) {
+		g := NewWithT(t)
+		guard := cliwrappers.NewRegistryGuard()
+		guard.MaxConcurrent = 2
+
+		lease1, err := guard.Acquire(context.Background(), "registry.io")
+		g.Expect(err).ToNot(HaveOccurred())
+		lease2, err := guard.Acquire(context.Background(), "registry.io", "quay.io")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = guard.Acquire(ctx, "registry.io")
+		g.Expect(err).To(MatchError(context.DeadlineExceeded))
+
+		acquired := make(chan error)
+		go func() {
+			lease, err := guard.Acquire(context.Background(), "quay.io", "registry.io")
+			if err == nil {
+				lease.Release(nil)
+			}
+			acquired <- err
+		}()
+		lease1.Release(nil)
+		g.Eventually(acquired).Should(Receive(BeNil()))
+		lease2.Release(nil)
+	})
+
+	t.Run("should ignore empty and duplicate hosts", func(t *testing.T) {
+		g := NewWithT(t)
+		guard := cliwrappers.NewRegistryGuard()
+		guard.MaxConcurrent = 1
+
+		lease, err := guard.Acquire(context.Background(), "", "registry.io", "registry.io")
+		g.Expect(err).ToNot(HaveOccurred())
+		lease.Release(nil)
+		// Double release is safe
+		lease.Release(nil)
+
+		lease, err = guard.Acquire(context.Background(), "registry.io")
+		g.Expect(err).ToNot(HaveOccurred())
+		lease.Release(nil)
+	})
+}
+
+func TestRegistryHost(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(cliwrappers.RegistryHost("quay.io/org/image:tag")).To(Equal("quay.io"))
+	g.Expect(cliwrappers.RegistryHost("registry.io:5000/image@sha256:4d6addf62a90e392ff6d3f470259eb5667eab5b9a8e03d20b41d0ab910f92170")).To(Equal("registry.io:5000"))
+	g.Expect(cliwrappers.RegistryHost("ubuntu")).To(Equal("docker.io"))
+	g.Expect(cliwrappers.RegistryHost("Invalid Ref")).To(BeEmpty())
+}
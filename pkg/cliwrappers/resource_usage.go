@@ -0,0 +1,143 @@
+package cliwrappers
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResourceUsage describes resources consumed by executed commands.
+type ResourceUsage struct {
+	WallTime   time.Duration `json:"wallTime"`
+	UserTime   time.Duration `json:"userTime"`
+	SystemTime time.Duration `json:"systemTime"`
+	// MaxRSS is the peak resident set size in bytes, 0 if not supported on the platform.
+	MaxRSS int64 `json:"maxRss"`
+}
+
+// newResourceUsage reads resource usage of a finished process.
+// Returns nil if the process didn't start.
+func newResourceUsage(state *os.ProcessState, wallTime time.Duration) *ResourceUsage {
+	if state == nil {
+		return nil
+	}
+	return &ResourceUsage{
+		WallTime:   wallTime,
+		UserTime:   state.UserTime(),
+		SystemTime: state.SystemTime(),
+		MaxRSS:     maxRSS(state),
+	}
+}
+
+// Add accumulates times of the other usage and keeps the highest peak memory.
+func (u *ResourceUsage) Add(other *ResourceUsage) {
+	if other == nil {
+		return
+	}
+	u.WallTime += other.WallTime
+	u.UserTime += other.UserTime
+	u.SystemTime += other.SystemTime
+	u.MaxRSS = max(u.MaxRSS, other.MaxRSS)
+}
+
+func (u *ResourceUsage) String() string {
+	return fmt.Sprintf("wall time: %v, user CPU: %v, system CPU: %v, peak memory: %s",
+		u.WallTime.Round(time.Millisecond), u.UserTime.Round(time.Millisecond), u.SystemTime.Round(time.Millisecond), formatBytes(u.MaxRSS))
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// ResourceStatistics aggregates resource usage of executed commands per tool,
+// so a command can report resources needed for its run.
+// It's safe for concurrent use.
+type ResourceStatistics struct {
+	mutex sync.Mutex
+	tools map[string]*ToolResourceUsage
+}
+
+// ToolResourceUsage is aggregated resource usage of all runs of a tool.
+type ToolResourceUsage struct {
+	ResourceUsage
+	// Runs is the number of executions of the tool.
+	Runs int `json:"runs"`
+}
+
+func NewResourceStatistics() *ResourceStatistics {
+	return &ResourceStatistics{}
+}
+
+// ExecutedCommandsUsage collects resource usage of all commands executed by CliExecutor in this process.
+var ExecutedCommandsUsage = NewResourceStatistics()
+
+// Add records resource usage of a single run of the given tool.
+func (s *ResourceStatistics) Add(tool string, usage *ResourceUsage) {
+	if usage == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.tools == nil {
+		s.tools = map[string]*ToolResourceUsage{}
+	}
+	toolUsage, ok := s.tools[tool]
+	if !ok {
+		toolUsage = &ToolResourceUsage{}
+		s.tools[tool] = toolUsage
+	}
+	toolUsage.Runs++
+	toolUsage.Add(usage)
+}
+
+// Summary returns current usage per tool.
+func (s *ResourceStatistics) Summary() map[string]ToolResourceUsage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	summary := make(map[string]ToolResourceUsage, len(s.tools))
+	for tool, usage := range s.tools {
+		summary[tool] = *usage
+	}
+	return summary
+}
+
+// Total returns usage of all tools together.
+// Peak memory is the highest peak of a single run, as the commands might run sequentially.
+func (s *ResourceStatistics) Total() ToolResourceUsage {
+	total := ToolResourceUsage{}
+	for _, usage := range s.Summary() {
+		total.Runs += usage.Runs
+		total.Add(&usage.ResourceUsage)
+	}
+	return total
+}
+
+// Format returns human readable report with a line per tool, empty if no command was executed.
+func (s *ResourceStatistics) Format() string {
+	summary := s.Summary()
+	if len(summary) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, tool := range slices.Sorted(maps.Keys(summary)) {
+		usage := summary[tool]
+		fmt.Fprintf(&sb, "%s: %d run(s), %s\n", tool, usage.Runs, usage.String())
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,15 @@
+package cliwrappers
+
+import (
+	"os"
+	"syscall"
+)
+
+// maxRSS returns the peak resident set size of the process in bytes.
+func maxRSS(state *os.ProcessState) int64 {
+	if rusage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		// Linux reports the value in kilobytes
+		return rusage.Maxrss * 1024
+	}
+	return 0
+}
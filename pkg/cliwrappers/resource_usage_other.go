@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package cliwrappers
+
+import "os"
+
+// maxRSS is not supported on this platform.
+func maxRSS(state *os.ProcessState) int64 {
+	return 0
+}
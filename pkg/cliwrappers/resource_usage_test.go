@@ -0,0 +1,75 @@
+package cliwrappers_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/cliwrappers"
+)
+
+func TestResourceStatistics(t *testing.T) {
+	t.Run("should aggregate usage per tool", func(t *testing.T) {
+		g := NewWithT(t)
+		statistics := cliwrappers.NewResourceStatistics()
+
+		statistics.Add("skopeo", &cliwrappers.ResourceUsage{WallTime: 2 * time.Second, UserTime: time.Second, SystemTime: 100 * time.Millisecond, MaxRSS: 30 << 20})
+		statistics.Add("skopeo", &cliwrappers.ResourceUsage{WallTime: 3 * time.Second, UserTime: time.Second, SystemTime: 200 * time.Millisecond, MaxRSS: 50 << 20})
+		statistics.Add("git", &cliwrappers.ResourceUsage{WallTime: time.Second, UserTime: 500 * time.Millisecond, MaxRSS: 10 << 20})
+		statistics.Add("git", nil)
+
+		summary := statistics.Summary()
+		g.Expect(summary).To(HaveLen(2))
+		g.Expect(summary["skopeo"].Runs).To(Equal(2))
+		g.Expect(summary["skopeo"].WallTime).To(Equal(5 * time.Second))
+		g.Expect(summary["skopeo"].UserTime).To(Equal(2 * time.Second))
+		g.Expect(summary["skopeo"].SystemTime).To(Equal(300 * time.Millisecond))
+		g.Expect(summary["skopeo"].MaxRSS).To(Equal(int64(50 << 20)))
+		g.Expect(summary["git"].Runs).To(Equal(1))
+
+		total := statistics.Total()
+		g.Expect(total.Runs).To(Equal(3))
+		g.Expect(total.WallTime).To(Equal(6 * time.Second))
+		g.Expect(total.MaxRSS).To(Equal(int64(50 << 20)))
+
+		g.Expect(statistics.Format()).To(Equal(
+			"git: 1 run(s), wall time: 1s, user CPU: 500ms, system CPU: 0s, peak memory: 10.0 MiB\n" +
+				"skopeo: 2 run(s), wall time: 5s, user CPU: 2s, system CPU: 300ms, peak memory: 50.0 MiB"))
+	})
+
+	t.Run("should format nothing if no command executed", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(cliwrappers.NewResourceStatistics().Format()).To(BeEmpty())
+	})
+}
+
+func TestCliExecutor_ResourceUsage(t *testing.T) {
+	t.Run("should measure executed command", func(t *testing.T) {
+		g := NewWithT(t)
+		executor := cliwrappers.NewCliExecutor()
+		runsBefore := cliwrappers.ExecutedCommandsUsage.Summary()["sleep"].Runs
+
+		result, err := executor.ExecuteWithOptions(context.Background(), nil, "sleep", "0.1")
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Usage).ToNot(BeNil())
+		g.Expect(result.Usage.WallTime).To(BeNumerically(">=", 100*time.Millisecond))
+		if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+			g.Expect(result.Usage.MaxRSS).To(BeNumerically(">", 0))
+		}
+		g.Expect(cliwrappers.ExecutedCommandsUsage.Summary()["sleep"].Runs).To(Equal(runsBefore + 1))
+	})
+
+	t.Run("should not report usage if command did not start", func(t *testing.T) {
+		g := NewWithT(t)
+		executor := cliwrappers.NewCliExecutor()
+
+		result, err := executor.ExecuteWithOptions(context.Background(), nil, "non-existing-command-kbc")
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(result.Usage).To(BeNil())
+	})
+}
@@ -0,0 +1,448 @@
+package cliwrappers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Clock provides time to retries, so they can be tested without real waiting.
+type Clock interface {
+	Now() time.Time
+	// Sleep waits for the given duration.
+	// Returns false if the context was done before the duration elapsed.
+	Sleep(ctx context.Context, duration time.Duration) bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// RealClock is the default clock based on the system time.
+var RealClock Clock = realClock{}
+
+// FakeClock is a Clock for tests.
+// Sleep returns immediately, advancing the clock time by the requested duration.
+type FakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Sleep(ctx context.Context, duration time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(duration)
+	c.sleeps = append(c.sleeps, duration)
+	return true
+}
+
+// Sleeps returns all durations requested via Sleep.
+func (c *FakeClock) Sleeps() []time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]time.Duration(nil), c.sleeps...)
+}
+
+// RetryPolicy defines when and how often an operation is retried.
+// It's used by Retryer for CLI calls and by Retry for in-process operations.
+// After the first failure, it waits BaseDelay before next attempt.
+// After each next failure, the dalay is multiplied by DelayFactor,
+// but cannot be greather than MaxDelay if MaxDelay is positive.
+// The delays can be randomized, see JitterStrategy.
+// Stop conditions:
+// - MaxAttempts is reached
+// - A stop condition matched the error
+// - The context is done or the next attempt would start after the context deadline.
+// - The next attempt would start after MaxDuration since the first attempt, if MaxDuration is positive.
+// - The error classifier, if set, reported a non-retryable failure, e.g. authentication error.
+// With a classifier set, the returned error is *ClassifiedError holding the class of the last failure.
+type RetryPolicy struct {
+	BaseDelay   time.Duration
+	DelayFactor float64
+	// MaxAttempts is the maximum number of attempts, the operation is always called at least once.
+	MaxAttempts int
+	MaxDelay    time.Duration
+	// MaxDuration limits the total time of all attempts and delays, if positive.
+	MaxDuration time.Duration
+	Jitter      JitterStrategy
+	// Clock measures time and performs delays, RealClock if nil.
+	Clock Clock
+
+	ctx            context.Context
+	stopConditions []func(err error) bool
+	classifier     func(err error) ErrorClass
+	statistics     *RetryStatistics
+}
+
+// JitterStrategy defines how delays between attempts are randomized.
+type JitterStrategy string
+
+const (
+	// JitterNone uses exact exponential delays.
+	JitterNone JitterStrategy = ""
+	// JitterFull waits a random time between zero and the exponential delay.
+	JitterFull JitterStrategy = "full"
+	// JitterDecorrelated waits a random time between BaseDelay and three times the previous delay.
+	JitterDecorrelated JitterStrategy = "decorrelated"
+)
+
+// RetryAttempt describes a single attempt of a retried operation.
+type RetryAttempt struct {
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	// ExitCode of a CLI call. In-process operations have 0 on success and 1 on failure.
+	ExitCode int `json:"exitCode"`
+	// Class of the failure, empty if the attempt succeeded.
+	Class ErrorClass `json:"class,omitempty"`
+}
+
+// DefaultMaxRetryAfter caps the wait requested by a rate limiting server, if the policy has no MaxDelay.
+const DefaultMaxRetryAfter = 5 * time.Minute
+
+// RetryAfterError can be implemented by errors of rate limited operations,
+// to make the retry wait at least the time requested by the server.
+// The requested time is capped by MaxDelay, or DefaultMaxRetryAfter if MaxDelay is not set.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		BaseDelay:   1 * time.Second,
+		DelayFactor: 2,
+		MaxAttempts: 3,
+
+		ctx: context.Background(),
+	}
+}
+
+// Retry calls the operation until it succeeds or the policy stops retrying.
+// Returns the value and error of the last attempt.
+func Retry[T any](policy *RetryPolicy, operation func(ctx context.Context) (T, error)) (T, error) {
+	value, _, err := RetryWithHistory(policy, operation)
+	return value, err
+}
+
+// RetryWithHistory calls the operation until it succeeds or the policy stops retrying.
+// Returns the value and error of the last attempt together with history of all attempts.
+func RetryWithHistory[T any](policy *RetryPolicy, operation func(ctx context.Context) (T, error)) (T, []RetryAttempt, error) {
+	hooks := retryHooks[T]{
+		exitCode: func(_ T, err error) int {
+			if err != nil {
+				return 1
+			}
+			return 0
+		},
+		retryAfter: func(_ T, err error) time.Duration {
+			var retryAfterErr RetryAfterError
+			if errors.As(err, &retryAfterErr) {
+				return retryAfterErr.RetryAfter()
+			}
+			return 0
+		},
+		describeFailure: func(_ T, err error) string {
+			return err.Error()
+		},
+	}
+	return runWithRetries(policy, operation, hooks)
+}
+
+// retryHooks adapt the retry loop to the kind of the retried operation.
+type retryHooks[T any] struct {
+	// exitCode returns the exit code to record in the attempt history.
+	exitCode func(value T, err error) int
+	// classify, if set, overrides the policy classifier.
+	classify func(value T, err error) ErrorClass
+	// stopReason, if set, returns non-empty reason if no more attempts should be performed.
+	stopReason func(value T, err error) string
+	// retryAfter returns the delay requested by a rate limiting server, if known.
+	retryAfter func(value T, err error) time.Duration
+	// describeFailure returns details of the failure for debug logs.
+	describeFailure func(value T, err error) string
+}
+
+// runWithRetries is the retry loop shared by all kinds of retried operations.
+func runWithRetries[T any](p *RetryPolicy, operation func(ctx context.Context) (T, error), hooks retryHooks[T]) (value T, attempts []RetryAttempt, err error) {
+	ctx := p.context()
+	clock := p.clock()
+	defer func() {
+		if p.statistics != nil {
+			p.statistics.Add(attempts)
+		}
+	}()
+
+	// Zero value policy still calls the operation
+	maxAttempts := max(p.MaxAttempts, 1)
+	retryerLog.Debugf("Running with max retries %d, %v interval, %.2f interval factor", maxAttempts, p.BaseDelay, p.DelayFactor)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return value, nil, fmt.Errorf("command was not run: %w", ctxErr)
+	}
+
+	classify := hooks.classify
+	if classify == nil && p.classifier != nil {
+		classify = func(_ T, err error) ErrorClass { return p.classifier(err) }
+	}
+	errorClass := ErrorClassUnknown
+	defer func() {
+		if err != nil && classify != nil {
+			err = &ClassifiedError{Class: errorClass, Err: err}
+		}
+	}()
+
+	// Stop retrying at the earliest of the context deadline and the retry time budget.
+	deadline, hasDeadline := ctx.Deadline()
+	if p.MaxDuration > 0 {
+		if budgetEnd := clock.Now().Add(p.MaxDuration); !hasDeadline || budgetEnd.Before(deadline) {
+			deadline, hasDeadline = budgetEnd, true
+		}
+	}
+
+	delay := p.BaseDelay
+	previousWait := p.BaseDelay
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		start := clock.Now()
+		value, err = operation(ctx)
+		record := RetryAttempt{
+			Start:    start,
+			Duration: clock.Now().Sub(start),
+			ExitCode: hooks.exitCode(value, err),
+		}
+		if err == nil {
+			attempts = append(attempts, record)
+			return value, attempts, nil
+		}
+		errorClass = ErrorClassUnknown
+		if classify != nil {
+			errorClass = classify(value, err)
+		}
+		record.Class = errorClass
+		attempts = append(attempts, record)
+
+		if hooks.stopReason != nil {
+			if reason := hooks.stopReason(value, err); reason != "" {
+				retryerLog.Debugf("Stopping retries after attempt %d, because %s", attempt, reason)
+				return value, attempts, err
+			}
+		}
+		for _, stopCondition := range p.stopConditions {
+			if stopCondition(err) {
+				retryerLog.Debugf("Stopping retries after attempt %d, because error matched stop condition: %s", attempt, err.Error())
+				return value, attempts, err
+			}
+		}
+
+		if !errorClass.IsRetryable() {
+			retryerLog.Debugf("Stopping retries after attempt %d, because of non-retryable %s error", attempt, errorClass)
+			return value, attempts, err
+		}
+
+		if attempt == maxAttempts {
+			// It was the last iteration, no need to wait after it.
+			retryerLog.Debugf("Attempt %d failed, %s", attempt, hooks.describeFailure(value, err))
+			break
+		}
+
+		wait := p.applyJitter(delay, previousWait)
+		if errorClass == ErrorClassRateLimited {
+			if retryAfter := hooks.retryAfter(value, err); retryAfter > wait {
+				retryerLog.Debugf("Rate limited, server asked to retry after %v", retryAfter)
+				wait = min(retryAfter, p.maxRetryAfter())
+			}
+		}
+
+		if hasDeadline && clock.Now().Add(wait).After(deadline) {
+			retryerLog.Debugf("Attempt %d failed, %s", attempt, hooks.describeFailure(value, err))
+			retryerLog.Infof("Giving up on command after %d attempts, next attempt would exceed the deadline", attempt)
+			return value, attempts, err
+		}
+
+		retryerLog.Debugf("Attempt %d failed, %s\nWaiting %v before next retry", attempt, hooks.describeFailure(value, err), wait)
+		if !clock.Sleep(ctx, wait) {
+			retryerLog.Infof("Giving up on command after %d attempts: %v", attempt, ctx.Err())
+			return value, attempts, err
+		}
+		previousWait = wait
+		delay = time.Duration(float64(delay) * p.DelayFactor)
+		if p.MaxDelay > 0 && delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+	}
+
+	retryerLog.Infof("Giving up on command after %d attempts", maxAttempts)
+	return value, attempts, err
+}
+
+func (p *RetryPolicy) context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
+
+// maxRetryAfter returns the longest wait requested by a rate limiting server the policy respects.
+func (p *RetryPolicy) maxRetryAfter() time.Duration {
+	if p.MaxDelay > 0 {
+		return p.MaxDelay
+	}
+	return DefaultMaxRetryAfter
+}
+
+func (p *RetryPolicy) clock() Clock {
+	if p.Clock == nil {
+		return RealClock
+	}
+	return p.Clock
+}
+
+// applyJitter randomizes the delay before the next attempt according to the jitter strategy.
+// Jitter prevents many clients, e.g. parallel pipeline tasks, from retrying in lockstep.
+func (p *RetryPolicy) applyJitter(delay, previousWait time.Duration) time.Duration {
+	switch p.Jitter {
+	case JitterFull:
+		// Random delay between 0 and the exponential delay
+		if delay <= 0 {
+			return 0
+		}
+		return rand.N(delay + 1)
+	case JitterDecorrelated:
+		// Random delay between the base delay and three times the previous delay
+		upper := previousWait * 3
+		if upper <= p.BaseDelay {
+			return p.BaseDelay
+		}
+		wait := p.BaseDelay + rand.N(upper-p.BaseDelay+1)
+		if p.MaxDelay > 0 && wait > p.MaxDelay {
+			wait = p.MaxDelay
+		}
+		return wait
+	default:
+		return delay
+	}
+}
+
+// WithContext binds the retries to the given context.
+// No more attempts are performed after the context is done or if the next attempt would start after the context deadline.
+func (p *RetryPolicy) WithContext(ctx context.Context) *RetryPolicy {
+	p.ctx = ctx
+	return p
+}
+
+// WithClock sets the clock used for delays and time measurements.
+func (p *RetryPolicy) WithClock(clock Clock) *RetryPolicy {
+	p.Clock = clock
+	return p
+}
+
+// WithBaseDelay sets the initial delay after a failure.
+// The delay will be increased by DelayFactor times after each failure.
+func (p *RetryPolicy) WithBaseDelay(baseInterval time.Duration) *RetryPolicy {
+	p.BaseDelay = baseInterval
+	return p
+}
+
+// WithDelayFactor sets the delay increasing factor after a failure.
+func (p *RetryPolicy) WithDelayFactor(delayFactor float64) *RetryPolicy {
+	p.DelayFactor = delayFactor
+	return p
+}
+
+// WithConstantDelay makes all dalays after failures of the same duration.
+func (p *RetryPolicy) WithConstantDelay(delay time.Duration) *RetryPolicy {
+	p.BaseDelay = delay
+	p.DelayFactor = 1
+	return p
+}
+
+// WithMaxAttempts sets maximum number or attempts before give up and fail.
+func (p *RetryPolicy) WithMaxAttempts(maxAttempts int) *RetryPolicy {
+	p.MaxAttempts = maxAttempts
+	return p
+}
+
+// WithMaxDelay sets maximum delay to wait between attempts.
+// If not set, no limit is appled.
+func (p *RetryPolicy) WithMaxDelay(maxDelay time.Duration) *RetryPolicy {
+	p.MaxDelay = maxDelay
+	return p
+}
+
+// WithMaxDuration limits the total time spent on all attempts and delays between them.
+func (p *RetryPolicy) WithMaxDuration(maxDuration time.Duration) *RetryPolicy {
+	p.MaxDuration = maxDuration
+	return p
+}
+
+// WithFullJitter makes each delay a random time between zero and the exponential delay.
+func (p *RetryPolicy) WithFullJitter() *RetryPolicy {
+	p.Jitter = JitterFull
+	return p
+}
+
+// WithDecorrelatedJitter makes each delay a random time between BaseDelay and three times the previous delay.
+// MaxDelay, if set, still applies.
+func (p *RetryPolicy) WithDecorrelatedJitter() *RetryPolicy {
+	p.Jitter = JitterDecorrelated
+	return p
+}
+
+// WithStatistics makes the policy add history of each run into the given statistics.
+func (p *RetryPolicy) WithStatistics(statistics *RetryStatistics) *RetryPolicy {
+	p.statistics = statistics
+	return p
+}
+
+// StopIf adds a stop condition.
+// If the condition returns true for the error of an attempt, no more retry attempts performed.
+func (p *RetryPolicy) StopIf(stopCondition func(err error) bool) *RetryPolicy {
+	p.stopConditions = append(p.stopConditions, stopCondition)
+	return p
+}
+
+// WithErrorClassifier sets the classifier which determines whether a failure is worth retrying.
+func (p *RetryPolicy) WithErrorClassifier(classifier func(err error) ErrorClass) *RetryPolicy {
+	p.classifier = classifier
+	return p
+}
+
+// WithImageRegistryPreset sets retry parameters for interacting with an image registry scenario.
+// Failures that cannot be fixed by retrying, like authentication or not found errors, are not retried.
+func (p *RetryPolicy) WithImageRegistryPreset() *RetryPolicy {
+	p.BaseDelay = 1 * time.Second
+	p.DelayFactor = 2
+	p.MaxAttempts = 10
+	p.MaxDelay = 4 * time.Minute
+	p.classifier = ClassifyRegistryFailure
+	return p
+}
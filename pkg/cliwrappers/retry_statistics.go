@@ -0,0 +1,68 @@
+package cliwrappers
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// RetryStatistics aggregates attempt histories of retried commands,
+// so a command can report how much retrying happened during its run.
+// It's safe for concurrent use.
+type RetryStatistics struct {
+	mutex   sync.Mutex
+	summary RetrySummary
+}
+
+// RetrySummary is a snapshot of RetryStatistics.
+type RetrySummary struct {
+	// Commands is the number of retried command runs.
+	Commands int `json:"commands"`
+	// Attempts is the total number of attempts of all commands.
+	Attempts int `json:"attempts"`
+	// Retries is the number of attempts after the first one of each command.
+	Retries int `json:"retries"`
+	// TotalDuration is the time spent on all attempts including delays between them.
+	TotalDuration time.Duration `json:"totalDuration"`
+	// FailuresByClass counts failed attempts by failure class.
+	FailuresByClass map[ErrorClass]int `json:"failuresByClass,omitempty"`
+}
+
+func NewRetryStatistics() *RetryStatistics {
+	return &RetryStatistics{}
+}
+
+// Add records history of a single command run.
+func (s *RetryStatistics) Add(attempts []RetryAttempt) {
+	if len(attempts) == 0 {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.summary.Commands++
+	s.summary.Attempts += len(attempts)
+	s.summary.Retries += len(attempts) - 1
+	last := attempts[len(attempts)-1]
+	s.summary.TotalDuration += last.Start.Add(last.Duration).Sub(attempts[0].Start)
+	for _, attempt := range attempts {
+		if attempt.Class == "" {
+			continue
+		}
+		if s.summary.FailuresByClass == nil {
+			s.summary.FailuresByClass = map[ErrorClass]int{}
+		}
+		s.summary.FailuresByClass[attempt.Class]++
+	}
+}
+
+// Summary returns current values of the statistics.
+func (s *RetryStatistics) Summary() RetrySummary {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	summary := s.summary
+	summary.FailuresByClass = maps.Clone(s.summary.FailuresByClass)
+	return summary
+}
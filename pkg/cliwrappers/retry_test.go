@@ -0,0 +1,245 @@
+package cliwrappers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/cliwrappers"
+)
+
+type rateLimitedError struct {
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Error() string {
+	return "429 too many requests"
+}
+
+func (e *rateLimitedError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+func TestRetry(t *testing.T) {
+	startTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should return value of successful attempt", func(t *testing.T) {
+		g := NewWithT(t)
+		clock := cliwrappers.NewFakeClock(startTime)
+		policy := cliwrappers.NewRetryPolicy().WithClock(clock).WithMaxAttempts(5)
+
+		callsCount := 0
+		value, err := cliwrappers.Retry(policy, func(ctx context.Context) (string, error) {
+			callsCount++
+			if callsCount < 3 {
+				return "", errors.New("temporary failure")
+			}
+			return "digest", nil
+		})
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(value).To(Equal("digest"))
+		g.Expect(callsCount).To(Equal(3))
+		g.Expect(clock.Sleeps()).To(Equal([]time.Duration{1 * time.Second, 2 * time.Second}))
+		g.Expect(clock.Now()).To(Equal(startTime.Add(3 * time.Second)))
+	})
+
+	t.Run("should call operation once with zero value policy", func(t *testing.T) {
+		g := NewWithT(t)
+		policy := &cliwrappers.RetryPolicy{Clock: cliwrappers.NewFakeClock(startTime)}
+
+		callsCount := 0
+		_, err := cliwrappers.Retry(policy, func(ctx context.Context) (string, error) {
+			callsCount++
+			return "", errors.New("failure")
+		})
+
+		g.Expect(err).To(MatchError("failure"))
+		g.Expect(callsCount).To(Equal(1))
+	})
+
+	t.Run("should return last error after max attempts", func(t *testing.T) {
+		g := NewWithT(t)
+		clock := cliwrappers.NewFakeClock(startTime)
+		policy := cliwrappers.NewRetryPolicy().WithClock(clock).WithConstantDelay(time.Minute).WithMaxAttempts(4)
+
+		callsCount := 0
+		_, attempts, err := cliwrappers.RetryWithHistory(policy, func(ctx context.Context) (int, error) {
+			callsCount++
+			return 0, errors.New("failure " + string(rune('0'+callsCount)))
+		})
+
+		g.Expect(err).To(MatchError("failure 4"))
+		g.Expect(callsCount).To(Equal(4))
+		g.Expect(attempts).To(HaveLen(4))
+		for i, attempt := range attempts {
+			g.Expect(attempt.ExitCode).To(Equal(1))
+			g.Expect(attempt.Start).To(Equal(startTime.Add(time.Duration(i) * time.Minute)))
+		}
+		g.Expect(clock.Sleeps()).To(HaveLen(3))
+	})
+
+	t.Run("should stop if stop condition matches", func(t *testing.T) {
+		g := NewWithT(t)
+		errFatal := errors.New("fatal")
+		policy := cliwrappers.NewRetryPolicy().WithClock(cliwrappers.NewFakeClock(startTime)).
+			StopIf(func(err error) bool { return errors.Is(err, errFatal) })
+
+		callsCount := 0
+		_, err := cliwrappers.Retry(policy, func(ctx context.Context) (bool, error) {
+			callsCount++
+			return false, errFatal
+		})
+
+		g.Expect(err).To(MatchError(errFatal))
+		g.Expect(callsCount).To(Equal(1))
+	})
+
+	t.Run("should not retry non-retryable errors of registry preset", func(t *testing.T) {
+		g := NewWithT(t)
+		policy := cliwrappers.NewRetryPolicy().WithImageRegistryPreset().WithClock(cliwrappers.NewFakeClock(startTime))
+
+		callsCount := 0
+		_, err := cliwrappers.Retry(policy, func(ctx context.Context) (string, error) {
+			callsCount++
+			return "", errors.New("401 unauthorized: authentication required")
+		})
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(cliwrappers.ErrorClassOf(err)).To(Equal(cliwrappers.ErrorClassAuth))
+		g.Expect(callsCount).To(Equal(1))
+	})
+
+	t.Run("should wait at least retry after time of rate limited errors", func(t *testing.T) {
+		g := NewWithT(t)
+		clock := cliwrappers.NewFakeClock(startTime)
+		policy := cliwrappers.NewRetryPolicy().WithImageRegistryPreset().WithClock(clock).WithMaxAttempts(2)
+
+		_, err := cliwrappers.Retry(policy, func(ctx context.Context) (string, error) {
+			return "", &rateLimitedError{retryAfter: 30 * time.Second}
+		})
+
+		g.Expect(cliwrappers.ErrorClassOf(err)).To(Equal(cliwrappers.ErrorClassRateLimited))
+		g.Expect(clock.Sleeps()).To(Equal([]time.Duration{30 * time.Second}))
+	})
+
+	t.Run("should cap retry after time by max delay", func(t *testing.T) {
+		g := NewWithT(t)
+		clock := cliwrappers.NewFakeClock(startTime)
+		policy := cliwrappers.NewRetryPolicy().WithImageRegistryPreset().WithClock(clock).WithMaxAttempts(2)
+
+		_, err := cliwrappers.Retry(policy, func(ctx context.Context) (string, error) {
+			return "", &rateLimitedError{retryAfter: time.Hour}
+		})
+
+		g.Expect(cliwrappers.ErrorClassOf(err)).To(Equal(cliwrappers.ErrorClassRateLimited))
+		g.Expect(clock.Sleeps()).To(Equal([]time.Duration{4 * time.Minute}))
+	})
+
+	t.Run("should cap retry after time without max delay", func(t *testing.T) {
+		g := NewWithT(t)
+		clock := cliwrappers.NewFakeClock(startTime)
+		policy := cliwrappers.NewRetryPolicy().WithImageRegistryPreset().WithMaxDelay(0).WithClock(clock).WithMaxAttempts(2)
+
+		_, err := cliwrappers.Retry(policy, func(ctx context.Context) (string, error) {
+			return "", &rateLimitedError{retryAfter: time.Hour}
+		})
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(clock.Sleeps()).To(Equal([]time.Duration{cliwrappers.DefaultMaxRetryAfter}))
+	})
+
+	t.Run("should give up if retry after time exceeds max duration", func(t *testing.T) {
+		g := NewWithT(t)
+		clock := cliwrappers.NewFakeClock(startTime)
+		policy := cliwrappers.NewRetryPolicy().WithImageRegistryPreset().WithClock(clock).WithMaxDuration(time.Minute)
+
+		callsCount := 0
+		_, err := cliwrappers.Retry(policy, func(ctx context.Context) (string, error) {
+			callsCount++
+			return "", &rateLimitedError{retryAfter: time.Hour}
+		})
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(callsCount).To(Equal(1))
+		g.Expect(clock.Sleeps()).To(BeEmpty())
+	})
+
+	t.Run("should respect max duration measured by the clock", func(t *testing.T) {
+		g := NewWithT(t)
+		clock := cliwrappers.NewFakeClock(startTime)
+		policy := cliwrappers.NewRetryPolicy().WithClock(clock).
+			WithBaseDelay(10 * time.Second).WithMaxAttempts(10).WithMaxDuration(time.Minute)
+
+		callsCount := 0
+		_, err := cliwrappers.Retry(policy, func(ctx context.Context) (string, error) {
+			callsCount++
+			return "", errors.New("failure")
+		})
+
+		g.Expect(err).To(HaveOccurred())
+		// Waits 10s, 20s, next 40s would exceed the budget
+		g.Expect(callsCount).To(Equal(3))
+		g.Expect(clock.Sleeps()).To(Equal([]time.Duration{10 * time.Second, 20 * time.Second}))
+	})
+
+	t.Run("should not call the operation if context is done", func(t *testing.T) {
+		g := NewWithT(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		policy := cliwrappers.NewRetryPolicy().WithContext(ctx).WithClock(cliwrappers.NewFakeClock(startTime))
+
+		isCalled := false
+		_, err := cliwrappers.Retry(policy, func(ctx context.Context) (string, error) {
+			isCalled = true
+			return "", nil
+		})
+
+		g.Expect(err).To(MatchError(context.Canceled))
+		g.Expect(isCalled).To(BeFalse())
+	})
+
+	t.Run("should collect statistics", func(t *testing.T) {
+		g := NewWithT(t)
+		statistics := cliwrappers.NewRetryStatistics()
+		policy := cliwrappers.NewRetryPolicy().WithClock(cliwrappers.NewFakeClock(startTime)).WithStatistics(statistics)
+
+		callsCount := 0
+		_, err := cliwrappers.Retry(policy, func(ctx context.Context) (string, error) {
+			callsCount++
+			if callsCount == 1 {
+				return "", errors.New("failure")
+			}
+			return "ok", nil
+		})
+
+		g.Expect(err).ToNot(HaveOccurred())
+		summary := statistics.Summary()
+		g.Expect(summary.Commands).To(Equal(1))
+		g.Expect(summary.Attempts).To(Equal(2))
+		g.Expect(summary.Retries).To(Equal(1))
+	})
+}
+
+func TestRetryer_WithFakeClock(t *testing.T) {
+	g := NewWithT(t)
+
+	clock := cliwrappers.NewFakeClock(time.Now())
+	callsCount := 0
+	retryer := cliwrappers.NewRetryer(func() (string, string, int, error) {
+		callsCount++
+		return "", "error", 1, errors.New("exit status 1")
+	}).WithClock(clock).WithBaseDelay(time.Hour).WithMaxAttempts(3)
+
+	start := time.Now()
+	_, _, exitCode, err := retryer.Run()
+
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(exitCode).To(Equal(1))
+	g.Expect(callsCount).To(Equal(3))
+	g.Expect(clock.Sleeps()).To(Equal([]time.Duration{time.Hour, 2 * time.Hour}))
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+}
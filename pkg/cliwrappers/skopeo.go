@@ -2,6 +2,8 @@ package cliwrappers
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -19,6 +21,28 @@ var _ SkopeoCliInterface = &SkopeoCli{}
 
 type SkopeoCli struct {
 	Executor CliExecutorInterface
+	// RetryStatistics, if set, collects retry history of all skopeo calls.
+	RetryStatistics *RetryStatistics
+	// Clock, if set, is used for delays between retries instead of the real time.
+	Clock Clock
+	// RegistryGuard limits calls to failing or overloaded registries, DefaultRegistryGuard if nil.
+	RegistryGuard *RegistryGuard
+	// ToolInfo describes the installed skopeo, all capabilities are assumed if nil.
+	ToolInfo *ToolInfo
+}
+
+const (
+	// SkopeoCapabilityMultiArchIndexOnly is support of "--multi-arch index-only" option of skopeo copy.
+	SkopeoCapabilityMultiArchIndexOnly = "multi-arch-index-only"
+)
+
+// SkopeoRequirement declares the skopeo version supported by the wrapper.
+var SkopeoRequirement = &ToolRequirement{
+	Name:       "skopeo",
+	MinVersion: ToolVersion{Major: 1, Minor: 6, Patch: 0},
+	Capabilities: map[string]ToolVersion{
+		SkopeoCapabilityMultiArchIndexOnly: {Major: 1, Minor: 9, Patch: 0},
+	},
 }
 
 func NewSkopeoCli(executor CliExecutorInterface) (*SkopeoCli, error) {
@@ -30,8 +54,14 @@ func NewSkopeoCli(executor CliExecutorInterface) (*SkopeoCli, error) {
 		return nil, errors.New("skopeo CLI is not available")
 	}
 
+	toolInfo, err := CheckCliToolVersion(executor, SkopeoRequirement)
+	if err != nil {
+		return nil, err
+	}
+
 	return &SkopeoCli{
 		Executor: executor,
+		ToolInfo: toolInfo,
 	}, nil
 }
 
@@ -59,6 +89,11 @@ func (s *SkopeoCli) Copy(args *SkopeoCopyArgs) error {
 		return errors.New("destination image is empty, image to copy to must be set")
 	}
 
+	if args.MultiArch == SkopeoCopyArgMultiArchIndexOnly && !s.ToolInfo.Supports(SkopeoCapabilityMultiArchIndexOnly) {
+		return fmt.Errorf("skopeo version %s does not support --multi-arch %s, at least version %s is required",
+			s.ToolInfo.Version, args.MultiArch, SkopeoRequirement.Capabilities[SkopeoCapabilityMultiArchIndexOnly])
+	}
+
 	scopeoArgs := []string{"copy"}
 
 	if args.MultiArch != "" {
@@ -75,24 +110,8 @@ func (s *SkopeoCli) Copy(args *SkopeoCopyArgs) error {
 	dockerPrefix := "docker://"
 	scopeoArgs = append(scopeoArgs, dockerPrefix+args.SourceImage, dockerPrefix+args.DestinationImage)
 
-	skopeoLog.Debugf("Running command:\nskopeo %s", strings.Join(scopeoArgs, " "))
-
-	retryer := NewRetryer(func() (string, string, int, error) {
-		return s.Executor.Execute("skopeo", scopeoArgs...)
-	}).WithImageRegistryPreset().StopIfOutputContains("unauthorized")
-
-	stdout, stderr, _, err := retryer.Run()
-	if err != nil {
-		skopeoLog.Errorf("skopeo copy failed: %s", err.Error())
-		skopeoLog.Infof("[stdout]:\n%s", stdout)
-		skopeoLog.Infof("[stderr]:\n%s", stderr)
-		return err
-	}
-
-	skopeoLog.Debug("[stdout]:\n" + stdout)
-	skopeoLog.Debug("[stderr]:\n" + stderr)
-
-	return nil
+	_, err := s.run(scopeoArgs, 0, args.SourceImage, args.DestinationImage)
+	return err
 }
 
 type SkopeoInspectArgs struct {
@@ -102,6 +121,8 @@ type SkopeoInspectArgs struct {
 	NoTags     bool
 	Format     string
 	ExtraArgs  []string
+	// MaxAttempts overrides the number of attempts of the registry retry preset, if positive.
+	MaxAttempts int
 }
 
 func (s *SkopeoCli) Inspect(args *SkopeoInspectArgs) (string, error) {
@@ -131,22 +152,59 @@ func (s *SkopeoCli) Inspect(args *SkopeoInspectArgs) (string, error) {
 	dockerPrefix := "docker://"
 	scopeoArgs = append(scopeoArgs, dockerPrefix+args.ImageRef)
 
-	skopeoLog.Debugf("Running command:\nskopeo %s", strings.Join(scopeoArgs, " "))
+	return s.run(scopeoArgs, args.MaxAttempts, args.ImageRef)
+}
+
+// run executes skopeo with retries suitable for the registries of the given images.
+// The default number of attempts is overridden by maxAttempts, if positive.
+// The registry guard is consulted once for the whole operation, so its own retries don't open the circuit,
+// only failures of whole operations do. Returns stdout of the last attempt.
+func (s *SkopeoCli) run(scopeoArgs []string, maxAttempts int, images ...string) (string, error) {
+	hosts := make([]string, 0, len(images))
+	for _, image := range images {
+		hosts = append(hosts, RegistryHost(image))
+	}
+
+	guard := s.RegistryGuard
+	if guard == nil {
+		guard = DefaultRegistryGuard
+	}
+	lease, err := guard.Acquire(executorContext(s.Executor), hosts...)
+	if err != nil {
+		skopeoLog.Errorf("skopeo %s failed: %s", scopeoArgs[0], err.Error())
+		return "", err
+	}
 
 	retryer := NewRetryer(func() (string, string, int, error) {
 		return s.Executor.Execute("skopeo", scopeoArgs...)
-	}).WithImageRegistryPreset().StopIfOutputContains("unauthorized")
+	}).WithContext(executorContext(s.Executor)).WithImageRegistryPreset().WithStatistics(s.RetryStatistics).WithClock(s.Clock)
+	if maxAttempts > 0 {
+		retryer.WithMaxAttempts(maxAttempts)
+	}
 
 	stdout, stderr, _, err := retryer.Run()
 	if err != nil {
-		skopeoLog.Errorf("skopeo inspect failed: %s", err.Error())
+		lease.ReleaseFailedHost(failedRegistryHost(stderr, hosts), err)
+		skopeoLog.Errorf("skopeo %s failed: %s", scopeoArgs[0], err.Error())
 		skopeoLog.Infof("[stdout]:\n%s", stdout)
 		skopeoLog.Infof("[stderr]:\n%s", stderr)
 		return "", err
 	}
-
-	skopeoLog.Debug("[stdout]:\n" + stdout)
-	skopeoLog.Debug("[stderr]:\n" + stderr)
-
+	lease.Release(nil)
 	return stdout, nil
 }
+
+// failedRegistryHost returns the host the failure of a skopeo call came from.
+// It's the only one of the hosts mentioned in the error output,
+// otherwise the last one, i.e. the destination of a copy.
+func failedRegistryHost(stderr string, hosts []string) string {
+	hosts = slices.DeleteFunc(slices.Clone(hosts), func(host string) bool { return host == "" })
+	if len(hosts) == 0 {
+		return ""
+	}
+	mentionedHosts := slices.DeleteFunc(slices.Clone(hosts), func(host string) bool { return !strings.Contains(stderr, host) })
+	if mentionedHosts = slices.Compact(mentionedHosts); len(mentionedHosts) == 1 {
+		return mentionedHosts[0]
+	}
+	return hosts[len(hosts)-1]
+}
@@ -5,6 +5,7 @@ import (
 	"slices"
 	"strconv"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 
@@ -21,8 +22,12 @@ func expectArgAndValue(g *WithT, args []string, argName string, argValue string)
 
 func setupSkopeoCli() (*cliwrappers.SkopeoCli, *mockExecutor) {
 	executor := &mockExecutor{}
-	skopeoCli := &cliwrappers.SkopeoCli{Executor: executor}
-	cliwrappers.DisableRetryer = true
+	// Retries don't wait real time with the fake clock
+	skopeoCli := &cliwrappers.SkopeoCli{
+		Executor:      executor,
+		Clock:         cliwrappers.NewFakeClock(time.Now()),
+		RegistryGuard: cliwrappers.NewRegistryGuard(),
+	}
 	return skopeoCli, executor
 }
 
@@ -133,6 +138,135 @@ func TestSkopeoCli_Copy(t *testing.T) {
 		g.Expect(isExecuteCalled).To(BeTrue())
 	})
 
+	t.Run("should retry failed copy without waiting real time", func(t *testing.T) {
+		skopeoCli, executor := setupSkopeoCli()
+		clock := skopeoCli.Clock.(*cliwrappers.FakeClock)
+		callsCount := 0
+		executor.executeFunc = func(command string, args ...string) (string, string, int, error) {
+			callsCount++
+			if callsCount < 3 {
+				return "", "connection reset by peer", 1, errors.New("exit status 1")
+			}
+			return "", "", 0, nil
+		}
+
+		copyArgs := &cliwrappers.SkopeoCopyArgs{
+			SourceImage:      "base",
+			DestinationImage: "target",
+		}
+
+		err := skopeoCli.Copy(copyArgs)
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(callsCount).To(Equal(3))
+		g.Expect(clock.Sleeps()).To(Equal([]time.Duration{1 * time.Second, 2 * time.Second}))
+	})
+
+	t.Run("should retry failing registry and stop calling it after failed operations", func(t *testing.T) {
+		g := NewWithT(t)
+		skopeoCli, executor := setupSkopeoCli()
+		callsCount := 0
+		executor.executeFunc = func(command string, args ...string) (string, string, int, error) {
+			callsCount++
+			return "", "dial tcp: connection refused", 1, errors.New("exit status 1")
+		}
+
+		copyArgs := &cliwrappers.SkopeoCopyArgs{
+			SourceImage:      "registry.io/org/base:1",
+			DestinationImage: "registry.io/org/target:1",
+		}
+
+		// Retries of an operation are not cut short by the circuit breaker
+		const maxAttempts = 10
+		for i := 1; i <= skopeoCli.RegistryGuard.FailureThreshold; i++ {
+			err := skopeoCli.Copy(copyArgs)
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(err).ToNot(MatchError(cliwrappers.ErrRegistryUnavailable))
+			g.Expect(callsCount).To(Equal(i * maxAttempts))
+		}
+
+		// Next operation fails fast
+		err := skopeoCli.Copy(copyArgs)
+		g.Expect(err).To(MatchError(cliwrappers.ErrRegistryUnavailable))
+		g.Expect(callsCount).To(Equal(skopeoCli.RegistryGuard.FailureThreshold * maxAttempts))
+	})
+
+	t.Run("should count failures only against the failing registry", func(t *testing.T) {
+		g := NewWithT(t)
+		skopeoCli, executor := setupSkopeoCli()
+		executor.executeFunc = func(command string, args ...string) (string, string, int, error) {
+			if args[0] == "inspect" {
+				return "{}", "", 0, nil
+			}
+			return "", "writing manifest: Put \"https://registry.io/v2/org/target/manifests/1\": dial tcp: connection refused", 1, errors.New("exit status 1")
+		}
+
+		copyArgs := &cliwrappers.SkopeoCopyArgs{
+			SourceImage:      "quay.io/org/base:1",
+			DestinationImage: "registry.io/org/target:1",
+		}
+		for i := 0; i < skopeoCli.RegistryGuard.FailureThreshold; i++ {
+			g.Expect(skopeoCli.Copy(copyArgs)).ToNot(Succeed())
+		}
+
+		_, err := skopeoCli.Inspect(&cliwrappers.SkopeoInspectArgs{ImageRef: "quay.io/org/base:1"})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = skopeoCli.Inspect(&cliwrappers.SkopeoInspectArgs{ImageRef: "registry.io/org/target:1"})
+		g.Expect(err).To(MatchError(cliwrappers.ErrRegistryUnavailable))
+	})
+
+	t.Run("should count copy failures without registry details against destination", func(t *testing.T) {
+		g := NewWithT(t)
+		skopeoCli, executor := setupSkopeoCli()
+		executor.executeFunc = func(command string, args ...string) (string, string, int, error) {
+			if args[0] == "inspect" {
+				return "{}", "", 0, nil
+			}
+			return "", "connection reset by peer", 1, errors.New("exit status 1")
+		}
+
+		copyArgs := &cliwrappers.SkopeoCopyArgs{
+			SourceImage:      "quay.io/org/base:1",
+			DestinationImage: "registry.io/org/target:1",
+		}
+		for i := 0; i < skopeoCli.RegistryGuard.FailureThreshold; i++ {
+			g.Expect(skopeoCli.Copy(copyArgs)).ToNot(Succeed())
+		}
+
+		_, err := skopeoCli.Inspect(&cliwrappers.SkopeoInspectArgs{ImageRef: "quay.io/org/base:1"})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = skopeoCli.Inspect(&cliwrappers.SkopeoInspectArgs{ImageRef: "registry.io/org/target:1"})
+		g.Expect(err).To(MatchError(cliwrappers.ErrRegistryUnavailable))
+	})
+
+	t.Run("should error if skopeo is too old for index-only multi-arch copy", func(t *testing.T) {
+		skopeoCli, executor := setupSkopeoCli()
+		executor.executeFunc = func(command string, args ...string) (string, string, int, error) {
+			if slices.Contains(args, "--version") {
+				return "skopeo version 1.7.0", "", 0, nil
+			}
+			t.Fatal("skopeo copy must not be called")
+			return "", "", 0, nil
+		}
+		toolInfo, err := cliwrappers.CheckCliToolVersion(executor, &cliwrappers.ToolRequirement{
+			Name:         "old-skopeo",
+			Capabilities: cliwrappers.SkopeoRequirement.Capabilities,
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		skopeoCli.ToolInfo = toolInfo
+
+		copyArgs := &cliwrappers.SkopeoCopyArgs{
+			SourceImage:      "base",
+			DestinationImage: "target",
+			MultiArch:        cliwrappers.SkopeoCopyArgMultiArchIndexOnly,
+		}
+		err = skopeoCli.Copy(copyArgs)
+
+		g.Expect(err).To(MatchError(ContainSubstring("skopeo version 1.7.0 does not support --multi-arch index-only")))
+	})
+
 	t.Run("should error if base image is empty", func(t *testing.T) {
 		skopeoCli, _ := setupSkopeoCli()
 		copyArgs := &cliwrappers.SkopeoCopyArgs{
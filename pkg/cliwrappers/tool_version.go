@@ -0,0 +1,144 @@
+package cliwrappers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+var toolVersionLog = l.Logger.WithField("logger", "ToolVersion")
+
+// ToolVersion is a semantic version of a CLI tool.
+type ToolVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+func (v ToolVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 if the version is lower than, equal to or greater than the other version.
+func (v ToolVersion) Compare(other ToolVersion) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if diff < 0 {
+			return -1
+		}
+		if diff > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast returns true if the version is equal to or greater than the given minimum.
+func (v ToolVersion) AtLeast(minimum ToolVersion) bool {
+	return v.Compare(minimum) >= 0
+}
+
+var toolVersionRegex = regexp.MustCompile(`\bv?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseToolVersion finds the first version in the output of "tool --version",
+// e.g. "skopeo version 1.20.0" or "git version 2.43.0".
+// Missing patch version is considered 0.
+func ParseToolVersion(output string) (ToolVersion, error) {
+	match := toolVersionRegex.FindStringSubmatch(output)
+	if match == nil {
+		return ToolVersion{}, fmt.Errorf("no version found in '%s'", output)
+	}
+	version := ToolVersion{}
+	version.Major, _ = strconv.Atoi(match[1])
+	version.Minor, _ = strconv.Atoi(match[2])
+	if match[3] != "" {
+		version.Patch, _ = strconv.Atoi(match[3])
+	}
+	return version, nil
+}
+
+// ToolRequirement declares which version of a CLI tool a wrapper needs.
+type ToolRequirement struct {
+	Name string
+	// MinVersion is the oldest version the wrapper works with.
+	MinVersion ToolVersion
+	// VersionArgs print the tool version, "--version" if not set.
+	VersionArgs []string
+	// Capabilities maps optional features to the oldest versions supporting them.
+	Capabilities map[string]ToolVersion
+}
+
+// ToolInfo describes the installed version of a CLI tool.
+type ToolInfo struct {
+	Name    string
+	Version ToolVersion
+	// VersionKnown is false if the version could not be determined.
+	// In such case the tool is assumed to support all capabilities.
+	VersionKnown bool
+
+	capabilities map[string]ToolVersion
+}
+
+// Supports returns true if the installed tool has the given capability.
+// Unknown capabilities, nil info and tools of unknown version are considered capable.
+func (t *ToolInfo) Supports(capability string) bool {
+	if t == nil || !t.VersionKnown {
+		return true
+	}
+	minVersion, ok := t.capabilities[capability]
+	return !ok || t.Version.AtLeast(minVersion)
+}
+
+var (
+	toolInfoCacheMutex sync.Mutex
+	toolInfoCache      = map[string]*ToolInfo{}
+)
+
+// CheckCliToolVersion determines the installed version of the tool and checks that it satisfies the requirement.
+// The version is determined once per process, following calls return the cached result.
+// If the version cannot be determined, a warning is logged and the tool is assumed to be recent enough.
+func CheckCliToolVersion(executor CliExecutorInterface, requirement *ToolRequirement) (*ToolInfo, error) {
+	toolInfoCacheMutex.Lock()
+	defer toolInfoCacheMutex.Unlock()
+
+	toolInfo, isCached := toolInfoCache[requirement.Name]
+	if !isCached {
+		toolInfo = detectToolVersion(executor, requirement)
+		toolInfoCache[requirement.Name] = toolInfo
+	}
+
+	if toolInfo.VersionKnown && !toolInfo.Version.AtLeast(requirement.MinVersion) {
+		return nil, fmt.Errorf("%s version %s is installed, but at least version %s is required",
+			requirement.Name, toolInfo.Version, requirement.MinVersion)
+	}
+	// Different wrappers of the same tool might declare different capabilities.
+	requiredToolInfo := *toolInfo
+	requiredToolInfo.capabilities = requirement.Capabilities
+	return &requiredToolInfo, nil
+}
+
+func detectToolVersion(executor CliExecutorInterface, requirement *ToolRequirement) *ToolInfo {
+	toolInfo := &ToolInfo{Name: requirement.Name}
+
+	versionArgs := requirement.VersionArgs
+	if len(versionArgs) == 0 {
+		versionArgs = []string{"--version"}
+	}
+	stdout, stderr, _, err := executor.Execute(requirement.Name, versionArgs...)
+	if err != nil {
+		toolVersionLog.Warnf("Failed to determine %s version, assuming it's recent enough: %s", requirement.Name, err.Error())
+		return toolInfo
+	}
+	version, err := ParseToolVersion(stdout + "\n" + stderr)
+	if err != nil {
+		toolVersionLog.Warnf("Failed to parse %s version, assuming it's recent enough: %s", requirement.Name, err.Error())
+		return toolInfo
+	}
+
+	toolVersionLog.Debugf("Detected %s version %s", requirement.Name, version)
+	toolInfo.Version = version
+	toolInfo.VersionKnown = true
+	return toolInfo
+}
@@ -0,0 +1,125 @@
+package cliwrappers_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/cliwrappers"
+)
+
+func TestParseToolVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	testCases := map[string]cliwrappers.ToolVersion{
+		"skopeo version 1.20.0":                     {Major: 1, Minor: 20, Patch: 0},
+		"git version 2.43.0\n":                      {Major: 2, Minor: 43, Patch: 0},
+		"buildah version 1.39.4 (image-spec 1.1.1)": {Major: 1, Minor: 39, Patch: 4},
+		"tool v0.5":                {Major: 0, Minor: 5, Patch: 0},
+		"podman version 5.4.0-dev": {Major: 5, Minor: 4, Patch: 0},
+	}
+	for output, expected := range testCases {
+		version, err := cliwrappers.ParseToolVersion(output)
+		g.Expect(err).ToNot(HaveOccurred(), output)
+		g.Expect(version).To(Equal(expected), output)
+	}
+
+	_, err := cliwrappers.ParseToolVersion("unknown version")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestToolVersion_Compare(t *testing.T) {
+	g := NewWithT(t)
+
+	v1_9_0 := cliwrappers.ToolVersion{Major: 1, Minor: 9}
+	v1_10_0 := cliwrappers.ToolVersion{Major: 1, Minor: 10}
+	v1_10_1 := cliwrappers.ToolVersion{Major: 1, Minor: 10, Patch: 1}
+
+	g.Expect(v1_9_0.Compare(v1_10_0)).To(Equal(-1))
+	g.Expect(v1_10_1.Compare(v1_10_0)).To(Equal(1))
+	g.Expect(v1_10_0.Compare(v1_10_0)).To(Equal(0))
+	g.Expect(v1_10_0.AtLeast(v1_9_0)).To(BeTrue())
+	g.Expect(v1_9_0.AtLeast(v1_10_1)).To(BeFalse())
+	g.Expect(v1_10_1.String()).To(Equal("1.10.1"))
+}
+
+func TestCheckCliToolVersion(t *testing.T) {
+	versionExecutor := func(output string, err error, callsCount *int) *mockExecutor {
+		return &mockExecutor{
+			executeFunc: func(command string, args ...string) (string, string, int, error) {
+				*callsCount++
+				return output, "", 0, err
+			},
+		}
+	}
+
+	t.Run("should detect version and capabilities", func(t *testing.T) {
+		g := NewWithT(t)
+		callsCount := 0
+		executor := versionExecutor("recent-tool version 1.5.2", nil, &callsCount)
+		requirement := &cliwrappers.ToolRequirement{
+			Name:       "recent-tool",
+			MinVersion: cliwrappers.ToolVersion{Major: 1, Minor: 2},
+			Capabilities: map[string]cliwrappers.ToolVersion{
+				"old-feature": {Major: 1, Minor: 5},
+				"new-feature": {Major: 2},
+			},
+		}
+
+		toolInfo, err := cliwrappers.CheckCliToolVersion(executor, requirement)
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(toolInfo.VersionKnown).To(BeTrue())
+		g.Expect(toolInfo.Version).To(Equal(cliwrappers.ToolVersion{Major: 1, Minor: 5, Patch: 2}))
+		g.Expect(toolInfo.Supports("old-feature")).To(BeTrue())
+		g.Expect(toolInfo.Supports("new-feature")).To(BeFalse())
+		g.Expect(toolInfo.Supports("undeclared-feature")).To(BeTrue())
+
+		// The version is cached
+		callsCountBefore := callsCount
+		_, err = cliwrappers.CheckCliToolVersion(executor, requirement)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(callsCount).To(Equal(callsCountBefore))
+	})
+
+	t.Run("should error if tool is too old", func(t *testing.T) {
+		g := NewWithT(t)
+		callsCount := 0
+		executor := versionExecutor("old-tool version 0.9.0", nil, &callsCount)
+		requirement := &cliwrappers.ToolRequirement{Name: "old-tool", MinVersion: cliwrappers.ToolVersion{Major: 1}}
+
+		_, err := cliwrappers.CheckCliToolVersion(executor, requirement)
+
+		g.Expect(err).To(MatchError("old-tool version 0.9.0 is installed, but at least version 1.0.0 is required"))
+	})
+
+	t.Run("should assume capable tool if version is unknown", func(t *testing.T) {
+		g := NewWithT(t)
+		callsCount := 0
+		executor := versionExecutor("development build", nil, &callsCount)
+		requirement := &cliwrappers.ToolRequirement{
+			Name:         "dev-tool",
+			MinVersion:   cliwrappers.ToolVersion{Major: 1},
+			Capabilities: map[string]cliwrappers.ToolVersion{"feature": {Major: 2}},
+		}
+
+		toolInfo, err := cliwrappers.CheckCliToolVersion(executor, requirement)
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(toolInfo.VersionKnown).To(BeFalse())
+		g.Expect(toolInfo.Supports("feature")).To(BeTrue())
+	})
+
+	t.Run("should assume capable tool if version command fails", func(t *testing.T) {
+		g := NewWithT(t)
+		callsCount := 0
+		executor := versionExecutor("", errors.New("unknown flag: --version"), &callsCount)
+		requirement := &cliwrappers.ToolRequirement{Name: "no-version-tool", MinVersion: cliwrappers.ToolVersion{Major: 1}}
+
+		toolInfo, err := cliwrappers.CheckCliToolVersion(executor, requirement)
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(toolInfo.VersionKnown).To(BeFalse())
+	})
+}
@@ -0,0 +1,206 @@
+package cliwrappers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+// TranscriptEntry is a single recorded command execution.
+type TranscriptEntry struct {
+	Command  string   `json:"command"`
+	Args     []string `json:"args"`
+	Dir      string   `json:"dir,omitempty"`
+	Stdout   string   `json:"stdout"`
+	Stderr   string   `json:"stderr"`
+	ExitCode int      `json:"exitCode"`
+	// Error is the message of the execution error, empty if the command succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// Transcript is a sequence of command executions, used to replay real tool runs in tests.
+// Sensitive arguments and registered secrets are masked, so transcripts can be committed.
+// It's safe for concurrent use.
+type Transcript struct {
+	mutex   sync.Mutex
+	Entries []TranscriptEntry `json:"entries"`
+}
+
+// LoadTranscript reads a transcript saved by Transcript.Save.
+func LoadTranscript(path string) (*Transcript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	transcript := &Transcript{}
+	if err := json.Unmarshal(data, transcript); err != nil {
+		return nil, fmt.Errorf("failed to parse transcript '%s': %w", path, err)
+	}
+	return transcript, nil
+}
+
+// Save writes the transcript into the given file, creating missing directories.
+func (t *Transcript) Save(path string) error {
+	t.mutex.Lock()
+	data, err := json.MarshalIndent(t, "", "  ")
+	t.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func (t *Transcript) add(entry TranscriptEntry) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.Entries = append(t.Entries, entry)
+}
+
+// newTranscriptEntry creates an entry of the call with sensitive data masked.
+func newTranscriptEntry(opts *ExecOptions, command string, args []string) TranscriptEntry {
+	entry := TranscriptEntry{Command: command, Args: RedactArgs(args)}
+	if opts != nil {
+		entry.Dir = opts.Dir
+	}
+	for i, arg := range entry.Args {
+		entry.Args[i] = l.Redact(arg)
+	}
+	return entry
+}
+
+func (e *TranscriptEntry) matches(other *TranscriptEntry) bool {
+	return e.Command == other.Command && slices.Equal(e.Args, other.Args) && e.Dir == other.Dir
+}
+
+func (e *TranscriptEntry) commandLine() string {
+	return FormatCommand(e.Command, e.Args)
+}
+
+// RecordingMiddleware records each execution into the transcript.
+func RecordingMiddleware(transcript *Transcript) Middleware {
+	return func(next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, opts *ExecOptions, command string, args ...string) (*ExecResult, error) {
+			result, err := next(ctx, opts, command, args...)
+
+			entry := newTranscriptEntry(opts, command, args)
+			entry.Stdout = l.Redact(result.Stdout)
+			entry.Stderr = l.Redact(result.Stderr)
+			entry.ExitCode = result.ExitCode
+			if err != nil {
+				entry.Error = l.Redact(err.Error())
+			}
+			transcript.add(entry)
+
+			return result, err
+		}
+	}
+}
+
+// NewRecordingExecutor returns an executor which runs commands by the given executor and records them into the transcript.
+func NewRecordingExecutor(executor CliExecutorInterface, transcript *Transcript) *ChainExecutor {
+	return NewChainExecutor(executor, RecordingMiddleware(transcript))
+}
+
+var _ CliExecutorInterface = &ReplayExecutor{}
+var _ CliExecutorWithOptionsInterface = &ReplayExecutor{}
+
+// ReplayExecutor serves recorded executions instead of running commands.
+// Calls must come in the recorded order with the same command, arguments and working directory.
+// An unexpected call fails with an error describing the expected one.
+type ReplayExecutor struct {
+	mutex      sync.Mutex
+	transcript *Transcript
+	next       int
+	// unexpected holds descriptions of calls that didn't match the transcript.
+	unexpected []string
+}
+
+func NewReplayExecutor(transcript *Transcript) *ReplayExecutor {
+	return &ReplayExecutor{transcript: transcript}
+}
+
+func (r *ReplayExecutor) Execute(command string, args ...string) (string, string, int, error) {
+	return r.ExecuteInDir("", command, args...)
+}
+
+func (r *ReplayExecutor) ExecuteInDir(workdir, command string, args ...string) (string, string, int, error) {
+	return unpackExecResult(r.ExecuteWithOptions(context.Background(), &ExecOptions{Dir: workdir}, command, args...))
+}
+
+func (r *ReplayExecutor) ExecuteWithOutput(command string, args ...string) (string, string, int, error) {
+	return r.ExecuteInDirWithOutput("", command, args...)
+}
+
+func (r *ReplayExecutor) ExecuteInDirWithOutput(workdir, command string, args ...string) (string, string, int, error) {
+	return unpackExecResult(r.ExecuteWithOptions(context.Background(), &ExecOptions{Dir: workdir, PrintOutput: true}, command, args...))
+}
+
+// ExecuteWithOptions returns the next recorded execution if it matches the call.
+func (r *ReplayExecutor) ExecuteWithOptions(ctx context.Context, opts *ExecOptions, command string, args ...string) (*ExecResult, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	call := newTranscriptEntry(opts, command, args)
+	if r.next >= len(r.transcript.Entries) {
+		return r.fail("unexpected call %s, no more calls recorded", call.commandLine())
+	}
+	expected := &r.transcript.Entries[r.next]
+	if !expected.matches(&call) {
+		return r.fail("unexpected call %s in '%s', expected %s in '%s'", call.commandLine(), call.Dir, expected.commandLine(), expected.Dir)
+	}
+	r.next++
+
+	result := &ExecResult{Stdout: expected.Stdout, Stderr: expected.Stderr, ExitCode: expected.ExitCode}
+	if expected.Error != "" {
+		return result, errors.New(expected.Error)
+	}
+	return result, nil
+}
+
+func (r *ReplayExecutor) fail(format string, args ...any) (*ExecResult, error) {
+	message := fmt.Sprintf(format, args...)
+	r.unexpected = append(r.unexpected, message)
+	return &ExecResult{ExitCode: -1}, errors.New(message)
+}
+
+// Verify returns error if there were unexpected calls or not all recorded calls were made.
+func (r *ReplayExecutor) Verify() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var errs []error
+	for _, message := range r.unexpected {
+		errs = append(errs, errors.New(message))
+	}
+	for _, entry := range r.transcript.Entries[r.next:] {
+		errs = append(errs, fmt.Errorf("expected call %s was not made", entry.commandLine()))
+	}
+	return errors.Join(errs...)
+}
+
+// NewGoldenExecutor returns an executor for tests backed by the transcript file at the given path.
+// If update is true, commands are run by the given executor and finish saves their transcript into the file.
+// Otherwise, the transcript is replayed and finish returns error if the calls didn't match it.
+func NewGoldenExecutor(path string, update bool, executor CliExecutorInterface) (CliExecutorInterface, func() error, error) {
+	if update {
+		transcript := &Transcript{}
+		return NewRecordingExecutor(executor, transcript), func() error { return transcript.Save(path) }, nil
+	}
+
+	transcript, err := LoadTranscript(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	replayExecutor := NewReplayExecutor(transcript)
+	return replayExecutor, replayExecutor.Verify, nil
+}
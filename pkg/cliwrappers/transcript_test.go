@@ -0,0 +1,152 @@
+package cliwrappers_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/cliwrappers"
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+// updateGolden makes golden transcript tests run real tools and rewrite their transcripts.
+var updateGolden = os.Getenv("KBC_UPDATE_GOLDEN") != ""
+
+func TestTranscript_RecordAndReplay(t *testing.T) {
+	g := NewWithT(t)
+	// The executor registers the password as secret for the whole process
+	t.Cleanup(func() { l.RemoveSecret("s3cret") })
+
+	transcript := &cliwrappers.Transcript{}
+	recorder := cliwrappers.NewRecordingExecutor(cliwrappers.NewCliExecutor(), transcript)
+
+	stdout, _, exitCode, err := recorder.Execute("echo", "hello", "--password", "s3cret")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(exitCode).To(Equal(0))
+	g.Expect(stdout).To(Equal("hello --password s3cret\n"))
+
+	tmpDir := t.TempDir()
+	_, _, exitCode, err = recorder.ExecuteInDir(tmpDir, "sh", "-c", "echo oops >&2; exit 3")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(exitCode).To(Equal(3))
+
+	path := filepath.Join(tmpDir, "golden", "transcript.json")
+	g.Expect(transcript.Save(path)).To(Succeed())
+	loaded, err := cliwrappers.LoadTranscript(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(loaded.Entries).To(HaveLen(2))
+	g.Expect(loaded.Entries[0].Args).To(Equal([]string{"hello", "--password", l.RedactedValue}))
+	g.Expect(loaded.Entries[0].Stdout).ToNot(ContainSubstring("s3cret"))
+	g.Expect(loaded.Entries[1].Dir).To(Equal(tmpDir))
+	g.Expect(loaded.Entries[1].Stderr).To(Equal("oops\n"))
+	g.Expect(loaded.Entries[1].ExitCode).To(Equal(3))
+
+	replayer := cliwrappers.NewReplayExecutor(loaded)
+	stdout, _, exitCode, err = replayer.Execute("echo", "hello", "--password", "another")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(exitCode).To(Equal(0))
+	g.Expect(stdout).To(Equal(loaded.Entries[0].Stdout))
+
+	_, stderr, exitCode, err := replayer.ExecuteInDir(tmpDir, "sh", "-c", "echo oops >&2; exit 3")
+	g.Expect(err).To(MatchError("exit status 3"))
+	g.Expect(exitCode).To(Equal(3))
+	g.Expect(stderr).To(Equal("oops\n"))
+
+	g.Expect(replayer.Verify()).To(Succeed())
+}
+
+func TestReplayExecutor_UnexpectedCalls(t *testing.T) {
+	g := NewWithT(t)
+
+	transcript := &cliwrappers.Transcript{Entries: []cliwrappers.TranscriptEntry{
+		{Command: "git", Args: []string{"fetch"}},
+		{Command: "git", Args: []string{"checkout", "main"}},
+	}}
+	replayer := cliwrappers.NewReplayExecutor(transcript)
+
+	_, _, exitCode, err := replayer.Execute("git", "pull")
+	g.Expect(err).To(MatchError(ContainSubstring("unexpected call git pull")))
+	g.Expect(err).To(MatchError(ContainSubstring("expected git fetch")))
+	g.Expect(exitCode).To(Equal(-1))
+
+	_, _, _, err = replayer.ExecuteInDir("/tmp", "git", "fetch")
+	g.Expect(err).To(MatchError(ContainSubstring("unexpected call git fetch in '/tmp'")))
+
+	_, _, _, err = replayer.Execute("git", "fetch")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	err = replayer.Verify()
+	g.Expect(err).To(MatchError(ContainSubstring("unexpected call git pull")))
+	g.Expect(err).To(MatchError(ContainSubstring("expected call git checkout main was not made")))
+
+	_, _, _, err = replayer.Execute("git", "checkout", "main")
+	g.Expect(err).ToNot(HaveOccurred())
+	_, _, _, err = replayer.Execute("git", "push")
+	g.Expect(err).To(MatchError(ContainSubstring("no more calls recorded")))
+}
+
+// setupGoldenSkopeoCli returns skopeo wrapper replaying the golden transcript, or recording it with KBC_UPDATE_GOLDEN.
+func setupGoldenSkopeoCli(t *testing.T, goldenPath string) (*cliwrappers.SkopeoCli, func() error) {
+	executor, finish, err := cliwrappers.NewGoldenExecutor(goldenPath, updateGolden, cliwrappers.NewCliExecutor())
+	if err != nil {
+		t.Fatal(err)
+	}
+	skopeoCli := &cliwrappers.SkopeoCli{
+		Executor:      executor,
+		Clock:         cliwrappers.NewFakeClock(time.Now()),
+		RegistryGuard: cliwrappers.NewRegistryGuard(),
+	}
+	return skopeoCli, finish
+}
+
+// Recording needs skopeo and access to quay.io.
+func TestSkopeoCli_Inspect_Golden(t *testing.T) {
+	g := NewWithT(t)
+	skopeoCli, finish := setupGoldenSkopeoCli(t, "testdata/skopeo_inspect.json")
+
+	digest, err := skopeoCli.Inspect(&cliwrappers.SkopeoInspectArgs{
+		ImageRef:    "quay.io/konflux-ci/buildah-task:latest",
+		NoTags:      true,
+		Format:      "{{.Digest}}",
+		MaxAttempts: 1,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(digest).To(HavePrefix("sha256:"))
+
+	_, err = skopeoCli.Inspect(&cliwrappers.SkopeoInspectArgs{
+		ImageRef:    "quay.io/konflux-ci/does-not-exist:latest",
+		NoTags:      true,
+		Format:      "{{.Digest}}",
+		MaxAttempts: 1,
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(cliwrappers.ErrorClassOf(err)).To(Equal(cliwrappers.ErrorClassAuth))
+
+	g.Expect(finish()).To(Succeed())
+}
+
+// Recording needs skopeo and push access to quay.io/konflux-ci/konflux-build-cli-golden.
+func TestSkopeoCli_Copy_Golden(t *testing.T) {
+	g := NewWithT(t)
+	skopeoCli, finish := setupGoldenSkopeoCli(t, "testdata/skopeo_copy.json")
+
+	err := skopeoCli.Copy(&cliwrappers.SkopeoCopyArgs{
+		SourceImage:      "quay.io/konflux-ci/buildah-task:latest",
+		DestinationImage: "quay.io/konflux-ci/konflux-build-cli-golden:copy",
+		RetryTimes:       3,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// Pushing to a repository without access fails without retries
+	err = skopeoCli.Copy(&cliwrappers.SkopeoCopyArgs{
+		SourceImage:      "quay.io/konflux-ci/buildah-task:latest",
+		DestinationImage: "quay.io/konflux-ci/does-not-exist:latest",
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(cliwrappers.ErrorClassOf(err)).To(Equal(cliwrappers.ErrorClassAuth))
+
+	g.Expect(finish()).To(Succeed())
+}
@@ -1,10 +1,12 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
 	cliWrappers "github.com/konflux-ci/konflux-build-cli/pkg/cliwrappers"
 	"github.com/konflux-ci/konflux-build-cli/pkg/common"
@@ -21,6 +23,7 @@ var ApplyTagsParamsConfig = map[string]common.Parameter{
 		TypeKind:   reflect.String,
 		Usage:      "Image name to add tags to. Tag and digest are ignored. Required.",
 		Required:   true,
+		Validators: []common.Validator{common.ValidateImageRef()},
 	},
 	"digest": {
 		Name:       "digest",
@@ -29,6 +32,7 @@ var ApplyTagsParamsConfig = map[string]common.Parameter{
 		TypeKind:   reflect.String,
 		Usage:      "Image digest to add tags to. Required.",
 		Required:   true,
+		Validators: []common.Validator{common.ValidateImageDigest()},
 	},
 	"tags": {
 		Name:         "tags",
@@ -37,6 +41,7 @@ var ApplyTagsParamsConfig = map[string]common.Parameter{
 		TypeKind:     reflect.Array,
 		DefaultValue: "",
 		Usage:        "Tags to add to the given image",
+		Validators:   []common.Validator{common.ValidateImageTag()},
 	},
 	"tags-from-image-label": {
 		Name:         "tags-from-image-label",
@@ -45,6 +50,10 @@ var ApplyTagsParamsConfig = map[string]common.Parameter{
 		TypeKind:     reflect.String,
 		DefaultValue: "",
 		Usage:        "Image label name to add tags from. Tags are comma or whitespace separated in the label value.",
+		Validators: []common.Validator{{
+			Description: "must be a label name of lowercase letters, digits and single . _ - / separators, starting and ending with a letter",
+			Check:       isImageLabelNameValid,
+		}},
 	},
 }
 
@@ -60,7 +69,7 @@ type ApplyTagsCliWrappers struct {
 }
 
 type ApplyTagsResults struct {
-	Tags []string `json:"tags"`
+	Tags []string `json:"tags" result:"tags" description:"Tags created for the image"`
 }
 
 type ApplyTags struct {
@@ -69,8 +78,9 @@ type ApplyTags struct {
 	Results       ApplyTagsResults
 	ResultsWriter common.ResultsWriterInterface
 
-	imageName     string
-	imageByDigest string
+	imageName       string
+	imageByDigest   string
+	retryStatistics *cliWrappers.RetryStatistics
 }
 
 func NewApplyTags(cmd *cobra.Command) (*ApplyTags, error) {
@@ -82,22 +92,28 @@ func NewApplyTags(cmd *cobra.Command) (*ApplyTags, error) {
 	}
 	applyTags.Params = params
 
-	if err := applyTags.initCliWrappers(); err != nil {
+	if err := applyTags.initCliWrappers(cmd.Context()); err != nil {
 		return nil, err
 	}
 
-	applyTags.ResultsWriter = common.NewResultsWriter()
+	resultsWriter, err := common.NewCommandResultsWriter(cmd)
+	if err != nil {
+		return nil, err
+	}
+	applyTags.ResultsWriter = resultsWriter
 
 	return applyTags, nil
 }
 
-func (c *ApplyTags) initCliWrappers() error {
-	executor := cliWrappers.NewCliExecutor()
+func (c *ApplyTags) initCliWrappers(ctx context.Context) error {
+	executor := cliWrappers.NewExecutor(ctx)
 
 	skopeoCli, err := cliWrappers.NewSkopeoCli(executor)
 	if err != nil {
 		return err
 	}
+	c.retryStatistics = cliWrappers.NewRetryStatistics()
+	skopeoCli.RetryStatistics = c.retryStatistics
 	c.CliWrappers.SkopeoCli = skopeoCli
 	return nil
 }
@@ -106,11 +122,8 @@ func (c *ApplyTags) initCliWrappers() error {
 func (c *ApplyTags) Run() error {
 	c.logParams()
 
+	// Parameters are checked by their validators, see ApplyTagsParamsConfig.
 	c.imageName = common.GetImageName(c.Params.ImageUrl)
-	if err := c.validateParams(); err != nil {
-		return err
-	}
-
 	c.imageByDigest = c.imageName + "@" + c.Params.Digest
 
 	var tagsFromLabel []string
@@ -139,7 +152,9 @@ func (c *ApplyTags) Run() error {
 	tags := append(c.Params.NewTags, tagsFromLabel...)
 	l.Logger.Debugf("Tags to create: %s", strings.Join(tags, ", "))
 
-	if err := c.applyTags(tags); err != nil {
+	err := c.applyTags(tags)
+	c.logRetryStatistics()
+	if err != nil {
 		return err
 	}
 
@@ -152,6 +167,11 @@ func (c *ApplyTags) Run() error {
 		return err
 	}
 
+	if err := c.ResultsWriter.WriteResults(c.Results); err != nil {
+		l.Logger.Errorf("failed to write results: %s", err.Error())
+		return err
+	}
+
 	return nil
 }
 
@@ -166,6 +186,19 @@ func (c *ApplyTags) logParams() {
 	}
 }
 
+// logRetryStatistics reports registry retries, if any, to help diagnosing slow runs.
+func (c *ApplyTags) logRetryStatistics() {
+	if c.retryStatistics == nil {
+		return
+	}
+	summary := c.retryStatistics.Summary()
+	if summary.Retries == 0 {
+		return
+	}
+	l.Logger.Infof("Registry calls: %d, attempts: %d, retries: %d, total time: %v, failures by class: %v",
+		summary.Commands, summary.Attempts, summary.Retries, summary.TotalDuration.Round(time.Millisecond), summary.FailuresByClass)
+}
+
 func (c *ApplyTags) retrieveTagsFromImageLabel(labelName string) ([]string, error) {
 	inspectArgs := &cliWrappers.SkopeoInspectArgs{
 		ImageRef:   c.imageByDigest,
@@ -211,35 +244,12 @@ func (c *ApplyTags) applyTags(tags []string) error {
 	return nil
 }
 
-func (c *ApplyTags) validateParams() error {
-	// Validate imageName instead of Params.ImageUrl to avoid calling normalizeImageName second time.
-	if !common.IsImageNameValid(c.imageName) {
-		return fmt.Errorf("image '%s' is invalid", c.imageName)
-	}
-
-	if !common.IsImageDigestValid(c.Params.Digest) {
-		return fmt.Errorf("image digest '%s' is invalid", c.Params.Digest)
-	}
-
-	for _, tag := range c.Params.NewTags {
-		if !common.IsImageTagValid(tag) {
-			return fmt.Errorf("tag '%s' is invalid", tag)
-		}
-	}
-
-	if c.Params.LabelWithTags != "" && !c.isImageLabelNameValid(c.Params.LabelWithTags) {
-		return fmt.Errorf("image label name '%s' is invalid", c.Params.LabelWithTags)
-	}
-
-	return nil
-}
-
 // isImageLabelNameValid checks if label key for docker image is valid.
 // Image label name can contain lowercase letters and digits plus underscore, period, dash and slash.
 // Image label should start and end with a letter.
 // Double separator is not allowed.
 // Image label max length is 256 characters.
-func (c *ApplyTags) isImageLabelNameValid(imageLabelName string) bool {
+func isImageLabelNameValid(imageLabelName string) bool {
 	if len(imageLabelName) == 0 || len(imageLabelName) > 256 {
 		return false
 	}
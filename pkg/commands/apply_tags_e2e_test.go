@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"context"
+	"net/http"
+	"os/exec"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/testregistry"
+)
+
+// requireSkopeo skips end to end tests if skopeo is not installed.
+func requireSkopeo(t *testing.T) {
+	if _, err := exec.LookPath("skopeo"); err != nil {
+		t.Skip("skopeo is not installed")
+	}
+}
+
+func runApplyTagsCommand(g *WithT, args ...string) error {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	cmd.Flags().String("image-url", "", "image")
+	cmd.Flags().String("digest", "", "digest")
+	cmd.Flags().StringArray("tags", nil, "tags")
+	cmd.Flags().String("tags-from-image-label", "", "label")
+	g.Expect(cmd.Flags().Parse(args)).To(Succeed())
+
+	applyTags, err := NewApplyTags(cmd)
+	g.Expect(err).ToNot(HaveOccurred())
+	return applyTags.Run()
+}
+
+func TestApplyTags_E2E(t *testing.T) {
+	requireSkopeo(t)
+
+	t.Run("should tag multi-arch image", func(t *testing.T) {
+		g := NewWithT(t)
+		registry := testregistry.New(t)
+		registry.ConfigureContainersTools(t)
+
+		labels := map[string]string{"extra-tags": "v1.0, v1"}
+		amd64 := registry.PushImage("org/app", "", testregistry.ImageConfig{Labels: labels}, []byte("amd64"))
+		arm64 := registry.PushImage("org/app", "", testregistry.ImageConfig{
+			Labels:   labels,
+			Platform: &testregistry.Platform{Architecture: "arm64", OS: "linux"},
+		}, []byte("arm64"))
+		index := registry.PushIndex("org/app", "build-1", amd64, arm64)
+
+		err := runApplyTagsCommand(g,
+			"--image-url", registry.Image("org/app:build-1"),
+			"--digest", amd64.Digest,
+			"--tags", "latest",
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(registry.ResolveTag("org/app", "latest")).To(Equal(amd64.Digest))
+
+		err = runApplyTagsCommand(g,
+			"--image-url", registry.Image("org/app"),
+			"--digest", index.Digest,
+			"--tags-from-image-label", "extra-tags",
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(registry.Tags("org/app")).To(Equal([]string{"build-1", "latest", "v1", "v1.0"}))
+		g.Expect(registry.ResolveTag("org/app", "v1")).To(Equal(index.Digest))
+	})
+
+	t.Run("should tag image in registry requiring token after rate limiting", func(t *testing.T) {
+		g := NewWithT(t)
+		registry := testregistry.New(t, testregistry.WithTokenAuth("builder", "secret"))
+		registry.ConfigureContainersTools(t)
+
+		image := registry.PushImage("app", "build-1", testregistry.ImageConfig{}, []byte("layer"))
+		registry.InjectFault(testregistry.Fault{
+			Method:       http.MethodPut,
+			PathContains: "/manifests/",
+			StatusCode:   http.StatusTooManyRequests,
+			Times:        1,
+		})
+
+		err := runApplyTagsCommand(g,
+			"--image-url", registry.Image("app"),
+			"--digest", image.Digest,
+			"--tags", "v1",
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(registry.ResolveTag("app", "v1")).To(Equal(image.Digest))
+	})
+
+	t.Run("should fail if image does not exist", func(t *testing.T) {
+		g := NewWithT(t)
+		registry := testregistry.New(t)
+		registry.ConfigureContainersTools(t)
+		registry.PushImage("app", "build-1", testregistry.ImageConfig{})
+
+		err := runApplyTagsCommand(g,
+			"--image-url", registry.Image("app"),
+			"--digest", "sha256:806a5df5f70987524b87da868672ba1cec327b4d35eed01f71f2765177b7754c",
+			"--tags", "v1",
+		)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(registry.Tags("app")).To(Equal([]string{"build-1"}))
+	})
+}
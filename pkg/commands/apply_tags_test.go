@@ -56,24 +56,23 @@ func Test_isImageLabelNameValid(t *testing.T) {
 		"label/_name",
 		"veryverylonglabelnameverylonglabelnameverylonglabelnameverylonglabelnameverylonglabelnameverylonglabelnameverylonglabelnameverylonglabelnameverylonglabelnameverylonglabelnameverylonglabelnameverylonglabelnameverylonglabelnameverylonglabelnameverylonglabelname",
 	}
-	c := &ApplyTags{}
 	for _, digest := range validImageLabelName {
 		t.Run("valid image label name", func(t *testing.T) {
-			if !c.isImageLabelNameValid(digest) {
+			if !isImageLabelNameValid(digest) {
 				t.Errorf("%s expected to be valid", digest)
 			}
 		})
 	}
 	for _, digest := range invalidImageLabelName {
 		t.Run("invalid image label name", func(t *testing.T) {
-			if c.isImageLabelNameValid(digest) {
+			if isImageLabelNameValid(digest) {
 				t.Errorf("%s expected to be invalid", digest)
 			}
 		})
 	}
 }
 
-func Test_validateParams(t *testing.T) {
+func Test_ApplyTagsParamsValidation(t *testing.T) {
 	g := NewWithT(t)
 	tests := []struct {
 		name         string
@@ -128,7 +127,7 @@ func Test_validateParams(t *testing.T) {
 				LabelWithTags: "konflux.additional-tags",
 			},
 			errExpected:  true,
-			errSubstring: "image",
+			errSubstring: "--image-url",
 		},
 		{
 			name: "should fail on invalid digets",
@@ -139,7 +138,7 @@ func Test_validateParams(t *testing.T) {
 				LabelWithTags: "konflux.additional-tags",
 			},
 			errExpected:  true,
-			errSubstring: "image digest",
+			errSubstring: "--digest",
 		},
 		{
 			name: "should fail on invalid tag",
@@ -150,7 +149,7 @@ func Test_validateParams(t *testing.T) {
 				LabelWithTags: "konflux.additional-tags",
 			},
 			errExpected:  true,
-			errSubstring: "tag",
+			errSubstring: "--tags",
 		},
 		{
 			name: "should fail on invalid label name",
@@ -161,16 +160,23 @@ func Test_validateParams(t *testing.T) {
 				LabelWithTags: "konflux.Additional-tags",
 			},
 			errExpected:  true,
-			errSubstring: "image label name",
+			errSubstring: "--tags-from-image-label",
 		},
 	}
-	c := &ApplyTags{}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			c.Params = &tc.params
-			c.imageName = common.GetImageName(c.Params.ImageUrl)
+			cmd := &cobra.Command{}
+			common.RegisterParameters(cmd, ApplyTagsParamsConfig)
+			args := []string{"--image-url", tc.params.ImageUrl, "--digest", tc.params.Digest}
+			for _, tag := range tc.params.NewTags {
+				args = append(args, "--tags", tag)
+			}
+			if tc.params.LabelWithTags != "" {
+				args = append(args, "--tags-from-image-label", tc.params.LabelWithTags)
+			}
+			g.Expect(cmd.Flags().Parse(args)).To(Succeed())
 
-			err := c.validateParams()
+			err := common.ParseParameters(cmd, ApplyTagsParamsConfig, &ApplyTagsParams{})
 
 			if tc.errExpected {
 				g.Expect(err).To(HaveOccurred())
@@ -434,6 +440,29 @@ func Test_Run(t *testing.T) {
 		g.Expect(isCreateResultJsonCalled).To(BeTrue())
 	})
 
+	t.Run("should write results", func(t *testing.T) {
+		beforeEach()
+		c.Params.NewTags = []string{"tag1"}
+
+		var writtenResults any
+		_mockResultsWriter.WriteResultsFunc = func(results any) error {
+			writtenResults = results
+			return nil
+		}
+
+		g.Expect(c.Run()).To(Succeed())
+		g.Expect(writtenResults).To(Equal(ApplyTagsResults{Tags: []string{"tag1"}}))
+	})
+
+	t.Run("should error if writing results failed", func(t *testing.T) {
+		beforeEach()
+		_mockResultsWriter.WriteResultsFunc = func(results any) error {
+			return errors.New("no space left on device")
+		}
+
+		g.Expect(c.Run()).To(MatchError("no space left on device"))
+	})
+
 	t.Run("should successfully run apply-tags with tags from label only", func(t *testing.T) {
 		beforeEach()
 		const labelWithTagsValue = "l1tag l2tag"
@@ -596,39 +625,6 @@ func Test_Run(t *testing.T) {
 		g.Expect(isScopeoInspectCalled).To(BeTrue())
 	})
 
-	t.Run("should error if a tag from parameter is invalid", func(t *testing.T) {
-		beforeEach()
-		tags := []string{"tag1", "tag@2"}
-		c.Params.NewTags = tags
-
-		err := c.Run()
-		g.Expect(err).To(HaveOccurred())
-	})
-
-	t.Run("should error if a image parameter is invalid", func(t *testing.T) {
-		beforeEach()
-		c.Params.ImageUrl = "image//url"
-
-		err := c.Run()
-		g.Expect(err).To(HaveOccurred())
-	})
-
-	t.Run("should error if a digest parameter is invalid", func(t *testing.T) {
-		beforeEach()
-		c.Params.Digest = "sha256:abcde1234"
-
-		err := c.Run()
-		g.Expect(err).To(HaveOccurred())
-	})
-
-	t.Run("should error if a image label parameter is invalid", func(t *testing.T) {
-		beforeEach()
-		c.Params.LabelWithTags = "Label"
-
-		err := c.Run()
-		g.Expect(err).To(HaveOccurred())
-	})
-
 	t.Run("should error if creation of result failed", func(t *testing.T) {
 		beforeEach()
 		c.Params.NewTags = []string{"tag"}
@@ -654,7 +650,7 @@ func Test_NewApplyTags(t *testing.T) {
 		cmd.Flags().StringArray("tags", nil, "tags")
 		parseErr := cmd.Flags().Parse([]string{
 			"--image-url", "image",
-			"--digest", "sha256:abcdef1234",
+			"--digest", "sha256:806a5df5f70987524b87da868672ba1cec327b4d35eed01f71f2765177b7754c",
 			"--tags", "tag",
 		})
 		g.Expect(parseErr).ToNot(HaveOccurred())
@@ -666,4 +662,24 @@ func Test_NewApplyTags(t *testing.T) {
 		g.Expect(applyTags.CliWrappers.SkopeoCli).ToNot(BeNil())
 		g.Expect(applyTags.ResultsWriter).ToNot(BeNil())
 	})
+
+	t.Run("should report all invalid parameters", func(t *testing.T) {
+		cmd := &cobra.Command{}
+		common.RegisterParameters(cmd, ApplyTagsParamsConfig)
+		parseErr := cmd.Flags().Parse([]string{
+			"--image-url", "image",
+			"--digest", "sha256:abcdef1234",
+			"--tags", "tag", "--tags", "-invalid",
+			"--tags-from-image-label", "Label",
+		})
+		g.Expect(parseErr).ToNot(HaveOccurred())
+
+		_, err := NewApplyTags(cmd)
+
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("parameter --digest (KBC_APPLY_TAGS_IMAGE_DIGEST) value 'sha256:abcdef1234' from flag is invalid: must be an image digest"))
+		g.Expect(err.Error()).To(ContainSubstring("parameter --tags (KBC_APPLY_TAGS) value '-invalid' from flag is invalid: must be an image tag"))
+		g.Expect(err.Error()).To(ContainSubstring("parameter --tags-from-image-label (KBC_APPLY_TAGS_FROM_IMAGE_LABEL) value 'Label' from flag is invalid"))
+		g.Expect(err.Error()).ToNot(ContainSubstring("image-url"))
+	})
 }
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/common"
+	"github.com/spf13/cobra"
+)
+
+// ConfigShow prints effective values of command parameters and where they come from.
+type ConfigShow struct {
+	// Commands to show parameters of.
+	Commands []*cobra.Command
+	Output   io.Writer
+}
+
+// NewConfigShow creates the command showing parameters of the command given by args, e.g. "image apply-tags",
+// or of all commands if args are empty.
+func NewConfigShow(cmd *cobra.Command, args []string) (*ConfigShow, error) {
+	configShow := &ConfigShow{Output: cmd.OutOrStdout()}
+
+	if len(args) == 0 {
+		configShow.Commands = common.CommandsWithParameters()
+		return configShow, nil
+	}
+
+	target, remainingArgs, err := cmd.Root().Find(args)
+	if err != nil || len(remainingArgs) != 0 || target == cmd.Root() {
+		return nil, fmt.Errorf("unknown command '%s'", strings.Join(args, " "))
+	}
+	if common.CommandParameters(target) == nil {
+		return nil, fmt.Errorf("command '%s' has no parameters", strings.Join(args, " "))
+	}
+	configShow.Commands = []*cobra.Command{target}
+	return configShow, nil
+}
+
+// Run executes the command logic.
+func (c *ConfigShow) Run() error {
+	w := tabwriter.NewWriter(c.Output, 0, 4, 2, ' ', 0)
+	for i, cmd := range c.Commands {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s:\n", common.CommandPathWithoutRoot(cmd))
+
+		params := common.CommandParameters(cmd)
+		names := make([]string, 0, len(params))
+		for name := range params {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			p := params[name]
+			value, err := common.ResolveParameter(cmd, p)
+			if err != nil {
+				// Missing required parameters are reported, not failed on, as the command is not being run.
+				fmt.Fprintf(w, "  %s\t<%s>\t\n", name, err.Error())
+				continue
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", name, formatParameterValue(p, value), formatParameterSource(p, value))
+		}
+	}
+	return w.Flush()
+}
+
+func formatParameterValue(p common.Parameter, value *common.ParameterValue) string {
+	if sensitiveEnvVarNameRegex.MatchString(p.Name) || sensitiveEnvVarNameRegex.MatchString(p.EnvVarName) {
+		if value.Value != "" || len(value.Values) != 0 {
+			return "***"
+		}
+	}
+	if value.Values != nil {
+		quoted := make([]string, len(value.Values))
+		for i, v := range value.Values {
+			quoted[i] = fmt.Sprintf("%q", v)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	return fmt.Sprintf("%q", value.Value)
+}
+
+func formatParameterSource(p common.Parameter, value *common.ParameterValue) string {
+	switch {
+	case value.Alias != "" && value.Source == common.ParameterSourceFlag:
+		return fmt.Sprintf("%s (--%s, deprecated)", value.Source, value.Alias)
+	case value.Alias != "":
+		return fmt.Sprintf("%s (%s, deprecated)", value.Source, value.Alias)
+	case value.Source == common.ParameterSourceEnv:
+		return fmt.Sprintf("%s (%s)", value.Source, p.EnvVarName)
+	case value.Source == common.ParameterSourceConfig:
+		return fmt.Sprintf("%s (%s)", value.Source, common.ConfigFilePath())
+	default:
+		return string(value.Source)
+	}
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/common"
+)
+
+func Test_ConfigShow(t *testing.T) {
+	g := NewWithT(t)
+
+	root := &cobra.Command{Use: "kbc"}
+	group := &cobra.Command{Use: "group"}
+	target := &cobra.Command{Use: "target"}
+	show := &cobra.Command{Use: "show"}
+	root.AddCommand(group, show)
+	group.AddCommand(target)
+	common.RegisterParameters(target, map[string]common.Parameter{
+		"image": {Name: "image", TypeKind: reflect.String, EnvVarName: "KBC_TEST_SHOW_IMAGE", Required: true,
+			EnvVarAliases: []string{"KBC_TEST_SHOW_OLD_IMAGE"}},
+		"tags":     {Name: "tags", TypeKind: reflect.Array},
+		"retries":  {Name: "retries", TypeKind: reflect.Int, DefaultValue: "3"},
+		"password": {Name: "password", TypeKind: reflect.String},
+	})
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	g.Expect(os.WriteFile(configPath, []byte("group target:\n  tags: [a, b]\n  password: s3cret\n"), 0644)).To(Succeed())
+	configFile, err := common.LoadConfigFile(configPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	common.SetConfigFile(configFile)
+	t.Cleanup(func() { common.SetConfigFile(nil) })
+
+	t.Run("should show parameter values and sources", func(t *testing.T) {
+		t.Setenv("KBC_TEST_SHOW_IMAGE", "quay.io/org/app")
+
+		configShow, err := NewConfigShow(show, []string{"group", "target"})
+		g.Expect(err).ToNot(HaveOccurred())
+		out := &bytes.Buffer{}
+		configShow.Output = out
+
+		g.Expect(configShow.Run()).To(Succeed())
+		g.Expect(out.String()).To(Equal("group target:\n" +
+			`  image     "quay.io/org/app"  env (KBC_TEST_SHOW_IMAGE)` + "\n" +
+			`  password  ***                config (` + configPath + ")\n" +
+			`  retries   "3"                default` + "\n" +
+			`  tags      ["a", "b"]         config (` + configPath + ")\n"))
+	})
+
+	t.Run("should report missing required parameter", func(t *testing.T) {
+		configShow, err := NewConfigShow(show, []string{"group", "target"})
+		g.Expect(err).ToNot(HaveOccurred())
+		out := &bytes.Buffer{}
+		configShow.Output = out
+
+		g.Expect(configShow.Run()).To(Succeed())
+		g.Expect(out.String()).To(ContainSubstring("<required parameter 'image' is not set>"))
+	})
+
+	t.Run("should show deprecated alias", func(t *testing.T) {
+		t.Setenv("KBC_TEST_SHOW_OLD_IMAGE", "quay.io/org/app")
+
+		configShow, err := NewConfigShow(show, []string{"group", "target"})
+		g.Expect(err).ToNot(HaveOccurred())
+		out := &bytes.Buffer{}
+		configShow.Output = out
+
+		g.Expect(configShow.Run()).To(Succeed())
+		g.Expect(out.String()).To(ContainSubstring(`"quay.io/org/app"  env (KBC_TEST_SHOW_OLD_IMAGE, deprecated)`))
+	})
+
+	t.Run("should fail on unknown command", func(t *testing.T) {
+		_, err := NewConfigShow(show, []string{"group", "unknown"})
+		g.Expect(err).To(MatchError("unknown command 'group unknown'"))
+
+		_, err = NewConfigShow(show, []string{"group"})
+		g.Expect(err).To(MatchError("command 'group' has no parameters"))
+	})
+
+	t.Run("should show all commands with parameters", func(t *testing.T) {
+		configShow, err := NewConfigShow(show, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(configShow.Commands).To(ContainElement(target))
+	})
+}
@@ -0,0 +1,421 @@
+package commands
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/common"
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+const (
+	docsFormatMarkdown = "markdown"
+	docsFormatMan      = "man"
+)
+
+var GenerateDocsParamsConfig = map[string]common.Parameter{
+	"output-dir": {
+		Name:         "output-dir",
+		ShortName:    "o",
+		EnvVarName:   "KBC_GENERATE_DOCS_OUTPUT_DIR",
+		TypeKind:     reflect.String,
+		DefaultValue: "docs/reference",
+		Usage:        "Directory to write the documentation files into, it's created if missing.",
+	},
+	"format": {
+		Name:         "format",
+		ShortName:    "f",
+		EnvVarName:   "KBC_GENERATE_DOCS_FORMATS",
+		TypeKind:     reflect.Array,
+		DefaultValue: docsFormatMarkdown,
+		Usage:        "Format of the documentation: markdown or man. Can be repeated.",
+		Validators:   []common.Validator{common.ValidateOneOf(docsFormatMarkdown, docsFormatMan)},
+	},
+}
+
+type GenerateDocsParams struct {
+	OutputDir string   `paramName:"output-dir"`
+	Formats   []string `paramName:"format"`
+}
+
+// GenerateDocs writes reference documentation of all commands and their parameters, one file per command.
+type GenerateDocs struct {
+	Params *GenerateDocsParams
+	// Root is the command to document with all its subcommands.
+	Root *cobra.Command
+}
+
+func NewGenerateDocs(cmd *cobra.Command) (*GenerateDocs, error) {
+	params := &GenerateDocsParams{}
+	if err := common.ParseParameters(cmd, GenerateDocsParamsConfig, params); err != nil {
+		return nil, err
+	}
+
+	return &GenerateDocs{
+		Params: params,
+		Root:   cmd.Root(),
+	}, nil
+}
+
+// commandDoc is the documentation of a command, independent of the output format.
+type commandDoc struct {
+	Path        string
+	Short       string
+	Long        string
+	UseLine     string
+	Parameters  []parameterDoc
+	Groups      []string
+	Results     []parameterDoc
+	GlobalFlags []parameterDoc
+	Parent      string
+	Subcommands []subcommandDoc
+}
+
+type parameterDoc struct {
+	Flag        string
+	ShortFlag   string
+	EnvVar      string
+	Type        string
+	Default     string
+	Required    bool
+	Description string
+}
+
+type subcommandDoc struct {
+	Path  string
+	Short string
+}
+
+// Run executes the command logic.
+func (c *GenerateDocs) Run() error {
+	if err := os.MkdirAll(c.Params.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	docs := []*commandDoc{}
+	collectCommandDocs(c.Root, &docs)
+
+	for _, doc := range docs {
+		for _, format := range c.Params.Formats {
+			var fileName, content string
+			switch format {
+			case docsFormatMan:
+				fileName = strings.ReplaceAll(doc.Path, " ", "-") + ".1"
+				content = renderManPage(doc)
+			default:
+				fileName = markdownFileName(doc.Path)
+				content = renderMarkdown(doc)
+			}
+
+			path := filepath.Join(c.Params.OutputDir, fileName)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				return fmt.Errorf("failed to write documentation file: %w", err)
+			}
+			l.Logger.Debugf("Wrote %s documentation of '%s' into '%s'", format, doc.Path, path)
+		}
+	}
+	l.Logger.Infof("Documented %d commands in '%s'", len(docs), c.Params.OutputDir)
+	return nil
+}
+
+// collectCommandDocs describes the command and all its available subcommands.
+func collectCommandDocs(cmd *cobra.Command, docs *[]*commandDoc) {
+	*docs = append(*docs, describeCommand(cmd))
+	for _, subcommand := range cmd.Commands() {
+		if !subcommand.IsAvailableCommand() || subcommand.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		collectCommandDocs(subcommand, docs)
+	}
+}
+
+func describeCommand(cmd *cobra.Command) *commandDoc {
+	doc := &commandDoc{
+		Path:    cmd.CommandPath(),
+		Short:   cmd.Short,
+		Long:    strings.TrimSpace(cmd.Long),
+		UseLine: cmd.UseLine(),
+	}
+	if cmd.HasParent() {
+		doc.Parent = cmd.Parent().CommandPath()
+	}
+	for _, subcommand := range cmd.Commands() {
+		if subcommand.IsAvailableCommand() && !subcommand.IsAdditionalHelpTopicCommand() {
+			doc.Subcommands = append(doc.Subcommands, subcommandDoc{Path: subcommand.CommandPath(), Short: subcommand.Short})
+		}
+	}
+
+	paramsConfig := common.CommandParameters(cmd)
+	for _, name := range slices.Sorted(maps.Keys(paramsConfig)) {
+		p := paramsConfig[name]
+		paramDoc := describeParameterDoc(cmd, p)
+		if _, isResult := resultParameterName(p); isResult {
+			doc.Results = append(doc.Results, paramDoc)
+		} else {
+			doc.Parameters = append(doc.Parameters, paramDoc)
+		}
+	}
+
+	for _, group := range common.CommandParameterGroups(cmd) {
+		flags := make([]string, len(group.Names))
+		for i, name := range group.Names {
+			flags[i] = "--" + name
+		}
+		doc.Groups = append(doc.Groups, fmt.Sprintf("%s: %s", group.Kind, strings.Join(flags, ", ")))
+	}
+
+	cmd.InheritedFlags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+		doc.GlobalFlags = append(doc.GlobalFlags, parameterDoc{
+			Flag:        flag.Name,
+			ShortFlag:   flag.Shorthand,
+			Type:        flag.Value.Type(),
+			Default:     flag.DefValue,
+			Description: flag.Usage,
+		})
+	})
+	return doc
+}
+
+func describeParameterDoc(cmd *cobra.Command, p common.Parameter) parameterDoc {
+	paramDoc := parameterDoc{
+		Flag:      p.Name,
+		ShortFlag: p.ShortName,
+		EnvVar:    p.EnvVarName,
+		Type:      parameterTypeName(p),
+		Default:   p.DefaultValue,
+		Required:  p.Required,
+	}
+	if flag := cmd.Flags().Lookup(p.Name); flag != nil && !p.Required && !isMultiValueParameter(p) {
+		// Show the default as the CLI does, e.g. "0" for numbers without explicit default
+		paramDoc.Default = flag.DefValue
+	}
+
+	var description []string
+	if usage := strings.TrimSpace(p.Usage); usage != "" {
+		if !strings.HasSuffix(usage, ".") {
+			usage += "."
+		}
+		description = append(description, usage)
+	}
+	for _, validator := range p.Validators {
+		description = append(description, fmt.Sprintf("Value %s.", validator.Description))
+	}
+	if p.FromFile {
+		description = append(description, "Accepts @path to read the value from a file.")
+	}
+	var aliases []string
+	for _, alias := range p.Aliases {
+		aliases = append(aliases, "--"+alias)
+	}
+	aliases = append(aliases, p.EnvVarAliases...)
+	if len(aliases) > 0 {
+		deprecation := fmt.Sprintf("Deprecated aliases: %s", strings.Join(aliases, ", "))
+		if p.AliasesRemovalVersion != "" {
+			deprecation += fmt.Sprintf(", to be removed in version %s", p.AliasesRemovalVersion)
+		}
+		description = append(description, deprecation+".")
+	}
+	paramDoc.Description = strings.Join(description, " ")
+	return paramDoc
+}
+
+func parameterTypeName(p common.Parameter) string {
+	switch p.TypeKind {
+	case reflect.Int:
+		return "int"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Float64:
+		return "float"
+	case reflect.Int64:
+		if p.Duration {
+			return "duration"
+		}
+		return "int"
+	case reflect.Array, reflect.Slice:
+		return "array"
+	case reflect.Map:
+		return "map"
+	default:
+		return "string"
+	}
+}
+
+func isMultiValueParameter(p common.Parameter) bool {
+	return p.TypeKind == reflect.Array || p.TypeKind == reflect.Slice || p.TypeKind == reflect.Map
+}
+
+func markdownFileName(commandPath string) string {
+	return strings.ReplaceAll(commandPath, " ", "_") + ".md"
+}
+
+func renderMarkdown(doc *commandDoc) string {
+	// Pipes would break table cells, angle brackets would be taken as HTML
+	cell := strings.NewReplacer("|", "\\|", "<", "&lt;", ">", "&gt;").Replace
+	code := func(value string) string {
+		if value == "" {
+			return ""
+		}
+		return "`" + value + "`"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", doc.Path)
+	if doc.Short != "" {
+		fmt.Fprintf(&b, "%s\n\n", doc.Short)
+	}
+	fmt.Fprintf(&b, "```\n%s\n```\n\n", doc.UseLine)
+	if doc.Long != "" && doc.Long != doc.Short {
+		fmt.Fprintf(&b, "## Description\n\n```\n%s\n```\n\n", doc.Long)
+	}
+
+	if len(doc.Parameters) > 0 {
+		b.WriteString("## Parameters\n\n")
+		b.WriteString("| Flag | Short | Environment variable | Type | Default | Required | Description |\n")
+		b.WriteString("|---|---|---|---|---|---|---|\n")
+		for _, p := range doc.Parameters {
+			required := ""
+			if p.Required {
+				required = "yes"
+			}
+			shortFlag := ""
+			if p.ShortFlag != "" {
+				shortFlag = "-" + p.ShortFlag
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n",
+				code("--"+p.Flag), code(shortFlag), code(p.EnvVar), p.Type, code(cell(p.Default)), required, cell(p.Description))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(doc.Groups) > 0 {
+		b.WriteString("Parameter groups:\n")
+		for _, group := range doc.Groups {
+			fmt.Fprintf(&b, "- %s\n", group)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(doc.Results) > 0 {
+		b.WriteString("## Results\n\n")
+		b.WriteString("Each result is written into the file given by its parameter.\n\n")
+		b.WriteString("| Flag | Environment variable | Description |\n")
+		b.WriteString("|---|---|---|\n")
+		for _, r := range doc.Results {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", code("--"+r.Flag), code(r.EnvVar), cell(r.Description))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(doc.GlobalFlags) > 0 {
+		b.WriteString("## Global flags\n\n")
+		b.WriteString("| Flag | Type | Default | Description |\n")
+		b.WriteString("|---|---|---|---|\n")
+		for _, f := range doc.GlobalFlags {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", code("--"+f.Flag), f.Type, code(cell(f.Default)), cell(f.Description))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(doc.Subcommands) > 0 {
+		b.WriteString("## Commands\n\n")
+		for _, subcommand := range doc.Subcommands {
+			fmt.Fprintf(&b, "- [%s](%s) - %s\n", subcommand.Path, markdownFileName(subcommand.Path), subcommand.Short)
+		}
+		b.WriteString("\n")
+	}
+
+	if doc.Parent != "" {
+		fmt.Fprintf(&b, "See also: [%s](%s)\n", doc.Parent, markdownFileName(doc.Parent))
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// roffEscape makes the text safe for man pages.
+func roffEscape(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\e`)
+	text = strings.ReplaceAll(text, "-", `\-`)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func renderManPage(doc *commandDoc) string {
+	manName := strings.ReplaceAll(doc.Path, " ", "-")
+	rootName := strings.Fields(doc.Path)[0]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH \"%s\" \"1\" \"\" \"%s\" \"\"\n", strings.ToUpper(manName), rootName)
+	fmt.Fprintf(&b, ".SH NAME\n%s \\- %s\n", roffEscape(manName), roffEscape(doc.Short))
+	fmt.Fprintf(&b, ".SH SYNOPSIS\n.B %s\n", roffEscape(doc.UseLine))
+	if doc.Long != "" {
+		fmt.Fprintf(&b, ".SH DESCRIPTION\n.nf\n%s\n.fi\n", roffEscape(doc.Long))
+	}
+
+	writeOptions := func(title string, params []parameterDoc) {
+		if len(params) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, ".SH %s\n", title)
+		for _, p := range params {
+			flags := "\\-\\-" + roffEscape(p.Flag)
+			if p.ShortFlag != "" {
+				flags = "\\-" + roffEscape(p.ShortFlag) + ", " + flags
+			}
+			fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", flags, roffEscape(p.Description))
+			if p.EnvVar != "" {
+				fmt.Fprintf(&b, ".br\nEnvironment variable: %s\n", roffEscape(p.EnvVar))
+			}
+			details := "Type: " + p.Type
+			if p.Required {
+				details += ", required"
+			} else if p.Default != "" {
+				details += ", default: " + roffEscape(p.Default)
+			}
+			fmt.Fprintf(&b, ".br\n%s\n", details)
+		}
+	}
+	writeOptions("OPTIONS", doc.Parameters)
+	if len(doc.Groups) > 0 {
+		b.WriteString(".PP\nParameter groups:\n")
+		for _, group := range doc.Groups {
+			fmt.Fprintf(&b, ".br\n%s\n", roffEscape(group))
+		}
+	}
+	writeOptions("RESULTS", doc.Results)
+	writeOptions("GLOBAL OPTIONS", doc.GlobalFlags)
+
+	var seeAlso []string
+	if doc.Parent != "" {
+		seeAlso = append(seeAlso, doc.Parent)
+	}
+	for _, subcommand := range doc.Subcommands {
+		seeAlso = append(seeAlso, subcommand.Path)
+	}
+	if len(seeAlso) > 0 {
+		b.WriteString(".SH SEE ALSO\n")
+		for i, path := range seeAlso {
+			separator := ","
+			if i == len(seeAlso)-1 {
+				separator = ""
+			}
+			fmt.Fprintf(&b, ".BR %s (1)%s\n", roffEscape(strings.ReplaceAll(path, " ", "-")), separator)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/common"
+)
+
+func Test_GenerateDocs(t *testing.T) {
+	g := NewWithT(t)
+
+	root := &cobra.Command{Use: "kbc", Short: "The CLI"}
+	root.PersistentFlags().String("loglevel", "info", "Logging level")
+	group := &cobra.Command{Use: "group", Short: "A group"}
+	target := &cobra.Command{Use: "target", Short: "Does something", Long: "Does something.\n.Really.", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(group)
+	group.AddCommand(target)
+	common.RegisterParameters(target, map[string]common.Parameter{
+		"image": {Name: "image", ShortName: "i", TypeKind: reflect.String, EnvVarName: "KBC_TARGET_IMAGE", Usage: "Image to use", Required: true,
+			Aliases: []string{"image-url"}, AliasesRemovalVersion: "1.0"},
+		"output":        {Name: "output", TypeKind: reflect.String, DefaultValue: "text", Usage: "Format|style", Validators: []common.Validator{common.ValidateOneOf("text", "json")}},
+		"retries":       {Name: "retries", TypeKind: reflect.Int, Usage: "Retries."},
+		"quiet":         {Name: "quiet", TypeKind: reflect.Bool, Usage: "No output"},
+		"result-digest": {Name: "result-digest", TypeKind: reflect.String, EnvVarName: "KBC_TARGET_RESULT_DIGEST", Usage: "Digest result file path"},
+	}, common.MutuallyExclusive("output", "quiet"))
+
+	outputDir := t.TempDir()
+	generateDocs := &GenerateDocs{
+		Params: &GenerateDocsParams{OutputDir: outputDir, Formats: []string{"markdown", "man"}},
+		Root:   root,
+	}
+	g.Expect(generateDocs.Run()).To(Succeed())
+
+	readFile := func(g *WithT, name string) string {
+		content, err := os.ReadFile(filepath.Join(outputDir, name))
+		g.Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	t.Run("should write a file per command and format", func(t *testing.T) {
+		g := NewWithT(t)
+		entries, err := os.ReadDir(outputDir)
+		g.Expect(err).ToNot(HaveOccurred())
+		names := []string{}
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		g.Expect(names).To(ConsistOf(
+			"kbc.md", "kbc_group.md", "kbc_group_target.md",
+			"kbc.1", "kbc-group.1", "kbc-group-target.1",
+		))
+	})
+
+	t.Run("should document parameters in Markdown", func(t *testing.T) {
+		g := NewWithT(t)
+		markdown := readFile(g, "kbc_group_target.md")
+		g.Expect(markdown).To(HavePrefix("# kbc group target\n\nDoes something\n\n```\nkbc group target [flags]\n```\n"))
+		g.Expect(markdown).To(ContainSubstring("| `--image` | `-i` | `KBC_TARGET_IMAGE` | string |  | yes | " +
+			"Image to use. Deprecated aliases: --image-url, to be removed in version 1.0. |\n"))
+		g.Expect(markdown).To(ContainSubstring("| `--output` |  |  | string | `text` |  | Format\\|style. Value must be one of: text, json. |\n"))
+		g.Expect(markdown).To(ContainSubstring("| `--retries` |  |  | int | `0` |  | Retries. |\n"))
+		g.Expect(markdown).To(ContainSubstring("- mutually exclusive: --output, --quiet\n"))
+		g.Expect(markdown).To(ContainSubstring("## Results\n"))
+		g.Expect(markdown).To(ContainSubstring("| `--result-digest` | `KBC_TARGET_RESULT_DIGEST` | Digest result file path. |\n"))
+		g.Expect(markdown).ToNot(ContainSubstring("| `--result-digest` |  |"))
+		g.Expect(markdown).To(ContainSubstring("| `--loglevel` | string | `info` | Logging level |\n"))
+		g.Expect(markdown).To(HaveSuffix("See also: [kbc group](kbc_group.md)\n"))
+	})
+
+	t.Run("should link subcommands in Markdown", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(readFile(g, "kbc_group.md")).To(ContainSubstring("- [kbc group target](kbc_group_target.md) - Does something\n"))
+	})
+
+	t.Run("should document parameters in man page", func(t *testing.T) {
+		g := NewWithT(t)
+		man := readFile(g, "kbc-group-target.1")
+		g.Expect(man).To(HavePrefix(".TH \"KBC-GROUP-TARGET\" \"1\" \"\" \"kbc\" \"\"\n.SH NAME\nkbc\\-group\\-target \\- Does something\n"))
+		g.Expect(man).To(ContainSubstring(".SH DESCRIPTION\n.nf\nDoes something.\n\\&.Really.\n.fi\n"))
+		g.Expect(man).To(ContainSubstring(".TP\n.B \\-i, \\-\\-image\nImage to use."))
+		g.Expect(man).To(ContainSubstring(".br\nEnvironment variable: KBC_TARGET_IMAGE\n.br\nType: string, required\n"))
+		g.Expect(man).To(ContainSubstring(".SH RESULTS\n.TP\n.B \\-\\-result\\-digest\n"))
+		g.Expect(man).To(HaveSuffix(".SH SEE ALSO\n.BR kbc\\-group (1)\n"))
+	})
+}
@@ -0,0 +1,301 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/common"
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+const (
+	tektonKindTask       = "Task"
+	tektonKindStepAction = "StepAction"
+)
+
+var GenerateTektonParamsConfig = map[string]common.Parameter{
+	"command": {
+		Name:       "command",
+		ShortName:  "c",
+		EnvVarName: "KBC_GENERATE_TEKTON_COMMAND",
+		TypeKind:   reflect.String,
+		Usage:      "Command to generate the definition for, e.g. \"image apply-tags\". Required.",
+		Required:   true,
+	},
+	"kind": {
+		Name:         "kind",
+		ShortName:    "k",
+		EnvVarName:   "KBC_GENERATE_TEKTON_KIND",
+		TypeKind:     reflect.String,
+		DefaultValue: tektonKindTask,
+		Usage:        "Kind of the definition: Task or StepAction.",
+		Validators:   []common.Validator{common.ValidateOneOf(tektonKindTask, tektonKindStepAction)},
+	},
+	"image": {
+		Name:         "image",
+		ShortName:    "i",
+		EnvVarName:   "KBC_GENERATE_TEKTON_IMAGE",
+		TypeKind:     reflect.String,
+		DefaultValue: "quay.io/konflux-ci/konflux-build-cli:latest",
+		Usage:        "Image with the CLI to run the step in.",
+		Validators:   []common.Validator{common.ValidateImageRef()},
+	},
+	"name": {
+		Name:         "name",
+		ShortName:    "n",
+		EnvVarName:   "KBC_GENERATE_TEKTON_NAME",
+		TypeKind:     reflect.String,
+		DefaultValue: "",
+		Usage:        "Name of the Task or StepAction. Defaults to the command name.",
+	},
+}
+
+type GenerateTektonParams struct {
+	Command string `paramName:"command"`
+	Kind    string `paramName:"kind"`
+	Image   string `paramName:"image"`
+	Name    string `paramName:"name"`
+}
+
+// GenerateTekton prints Tekton Task or StepAction running a command of the CLI.
+// Parameters of the command become Tekton params, passed via environment variables where possible,
+// and result-* parameters become Tekton results.
+type GenerateTekton struct {
+	Params *GenerateTektonParams
+	// Target is the command to generate the definition for.
+	Target *cobra.Command
+	Output io.Writer
+}
+
+func NewGenerateTekton(cmd *cobra.Command) (*GenerateTekton, error) {
+	params := &GenerateTektonParams{}
+	if err := common.ParseParameters(cmd, GenerateTektonParamsConfig, params); err != nil {
+		return nil, err
+	}
+
+	target, remainingArgs, err := cmd.Root().Find(strings.Fields(params.Command))
+	if err != nil || len(remainingArgs) != 0 || target == cmd.Root() {
+		return nil, fmt.Errorf("unknown command '%s'", params.Command)
+	}
+	if common.CommandParameters(target) == nil {
+		return nil, fmt.Errorf("command '%s' has no parameters", params.Command)
+	}
+
+	return &GenerateTekton{
+		Params: params,
+		Target: target,
+		Output: cmd.OutOrStdout(),
+	}, nil
+}
+
+type tektonMetadata struct {
+	Name string `yaml:"name"`
+}
+
+type tektonParam struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Type        string `yaml:"type"`
+	// Default is nil for required parameters. Empty string or array is written, making the param optional.
+	Default any `yaml:"default,omitempty"`
+}
+
+type tektonResult struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Type        string `yaml:"type,omitempty"`
+}
+
+type tektonEnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// tektonStep holds fields shared by Task steps and StepAction spec.
+type tektonStep struct {
+	Name    string         `yaml:"name,omitempty"`
+	Image   string         `yaml:"image"`
+	Command []string       `yaml:"command"`
+	Env     []tektonEnvVar `yaml:"env,omitempty"`
+	Args    []string       `yaml:"args,omitempty"`
+}
+
+type tektonTaskSpec struct {
+	Description string         `yaml:"description,omitempty"`
+	Params      []tektonParam  `yaml:"params,omitempty"`
+	Results     []tektonResult `yaml:"results,omitempty"`
+	Steps       []tektonStep   `yaml:"steps"`
+}
+
+type tektonStepActionSpec struct {
+	Description string         `yaml:"description,omitempty"`
+	Params      []tektonParam  `yaml:"params,omitempty"`
+	Results     []tektonResult `yaml:"results,omitempty"`
+	tektonStep  `yaml:",inline"`
+}
+
+type tektonResource struct {
+	APIVersion string         `yaml:"apiVersion"`
+	Kind       string         `yaml:"kind"`
+	Metadata   tektonMetadata `yaml:"metadata"`
+	Spec       any            `yaml:"spec"`
+}
+
+// Run executes the command logic.
+func (c *GenerateTekton) Run() error {
+	name := c.Params.Name
+	if name == "" {
+		name = c.Target.Name()
+	}
+
+	params, results, step := c.buildDefinition()
+
+	resource := tektonResource{
+		Kind:     c.Params.Kind,
+		Metadata: tektonMetadata{Name: name},
+	}
+	if c.Params.Kind == tektonKindStepAction {
+		resource.APIVersion = "tekton.dev/v1beta1"
+		resource.Spec = tektonStepActionSpec{
+			Description: c.Target.Short,
+			Params:      params,
+			Results:     results,
+			tektonStep:  step,
+		}
+	} else {
+		step.Name = name
+		resource.APIVersion = "tekton.dev/v1"
+		resource.Spec = tektonTaskSpec{
+			Description: c.Target.Short,
+			Params:      params,
+			Results:     results,
+			Steps:       []tektonStep{step},
+		}
+	}
+
+	l.Logger.Debugf("Generating Tekton %s for '%s' command", c.Params.Kind, common.CommandPathWithoutRoot(c.Target))
+
+	encoder := yaml.NewEncoder(c.Output)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(resource); err != nil {
+		return fmt.Errorf("failed to write Tekton %s: %w", c.Params.Kind, err)
+	}
+	return encoder.Close()
+}
+
+// buildDefinition converts parameters of the target command into Tekton params, results and the step running the command.
+func (c *GenerateTekton) buildDefinition() ([]tektonParam, []tektonResult, tektonStep) {
+	resultPathFormat := "$(results.%s.path)"
+	if c.Params.Kind == tektonKindStepAction {
+		resultPathFormat = "$(step.results.%s.path)"
+	}
+
+	params := []tektonParam{}
+	results := []tektonResult{}
+	step := tektonStep{
+		Image:   c.Params.Image,
+		Command: strings.Fields(c.Target.CommandPath()),
+	}
+
+	// Array results declared in the results struct are JSON arrays, other types are written as strings
+	resultTypes := map[string]string{}
+	resultDescriptions := map[string]string{}
+	for _, result := range common.CommandResults(c.Target) {
+		resultDescriptions[result.Param.Name] = result.Description
+		if kind := result.Type.Kind(); (kind == reflect.Slice || kind == reflect.Array) && result.Type.Elem().Kind() == reflect.String {
+			resultTypes[result.Param.Name] = "array"
+		}
+	}
+
+	paramsConfig := common.CommandParameters(c.Target)
+	for _, name := range slices.Sorted(maps.Keys(paramsConfig)) {
+		p := paramsConfig[name]
+
+		// Result file paths are provided by Tekton
+		if resultName, isResult := resultParameterName(p); isResult {
+			tektonName := toTektonName(resultName)
+			// The parameter usage describes the file path, not the result
+			description, isRegistered := resultDescriptions[p.Name]
+			if !isRegistered {
+				description = p.Usage
+			}
+			results = append(results, tektonResult{Name: tektonName, Description: description, Type: resultTypes[p.Name]})
+			resultPath := fmt.Sprintf(resultPathFormat, tektonName)
+			if p.EnvVarName != "" {
+				step.Env = append(step.Env, tektonEnvVar{Name: p.EnvVarName, Value: resultPath})
+			} else {
+				step.Args = append(step.Args, fmt.Sprintf("--%s=%s", p.Name, resultPath))
+			}
+			continue
+		}
+
+		tektonName := toTektonName(p.Name)
+		param := tektonParam{Name: tektonName, Description: p.Usage, Type: "string"}
+
+		// Optional params are empty by default, so the CLI applies its own default.
+		// A value given to the CLI would take precedence over the config file
+		// and would count as set in parameter groups, even if it equals the default.
+		if isMultiValueParameter(p) {
+			// Tekton arrays cannot be passed via environment variables.
+			// The end marker allows values starting with a dash. No values mean the flag is not given at all.
+			param.Type = "array"
+			if !p.Required {
+				param.Default = []string{}
+				param.Description = describeTektonDefault(param.Description, p.DefaultValue)
+			}
+			step.Args = append(step.Args, "--"+p.Name, fmt.Sprintf("$(params.%s[*])", tektonName), common.ArrayEndMarker)
+			params = append(params, param)
+			continue
+		}
+
+		// Use the default as the CLI shows it, e.g. "0" for numbers without explicit default
+		cliDefault := p.DefaultValue
+		if flag := c.Target.Flags().Lookup(p.Name); flag != nil {
+			cliDefault = flag.DefValue
+		}
+		value := fmt.Sprintf("$(params.%s)", tektonName)
+		if p.EnvVarName != "" {
+			// Environment variables avoid interpretation of the values as arguments, empty value means not set
+			step.Env = append(step.Env, tektonEnvVar{Name: p.EnvVarName, Value: value})
+			if !p.Required {
+				param.Default = ""
+				param.Description = describeTektonDefault(param.Description, cliDefault)
+			}
+		} else {
+			// An empty argument is not a valid value of all types, e.g. numbers, so the CLI default is passed
+			step.Args = append(step.Args, fmt.Sprintf("--%s=%s", p.Name, value))
+			if !p.Required {
+				param.Default = cliDefault
+			}
+		}
+		params = append(params, param)
+	}
+
+	return params, results, step
+}
+
+// describeTektonDefault adds the CLI default of a param, which is empty in Tekton, to its description.
+func describeTektonDefault(description, cliDefault string) string {
+	if cliDefault == "" {
+		return description
+	}
+	return fmt.Sprintf("%s (default: %s)", description, cliDefault)
+}
+
+// resultParameterName returns the result name if the parameter holds a result file path, e.g. digest for result-digest.
+func resultParameterName(p common.Parameter) (string, bool) {
+	resultName, isResult := strings.CutPrefix(p.Name, common.ResultParamPrefix)
+	return resultName, isResult && p.TypeKind == reflect.String
+}
+
+// toTektonName converts a parameter name into Tekton param or result name, e.g. image-url into IMAGE_URL.
+func toTektonName(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/common"
+)
+
+func Test_GenerateTekton(t *testing.T) {
+	g := NewWithT(t)
+
+	root := &cobra.Command{Use: "kbc"}
+	group := &cobra.Command{Use: "group"}
+	target := &cobra.Command{Use: "target", Short: "Does something"}
+	root.AddCommand(group)
+	group.AddCommand(target)
+	common.RegisterParameters(target, map[string]common.Parameter{
+		"image":         {Name: "image", TypeKind: reflect.String, EnvVarName: "KBC_TARGET_IMAGE", Usage: "Image to use", Required: true},
+		"tags":          {Name: "tags", TypeKind: reflect.Array, DefaultValue: "latest", Usage: "Tags"},
+		"retries":       {Name: "retries", TypeKind: reflect.Int, Usage: "Retries"},
+		"platform":      {Name: "platform", TypeKind: reflect.String, EnvVarName: "KBC_TARGET_PLATFORM", DefaultValue: "linux/amd64", Usage: "Platform"},
+		"result-digest": {Name: "result-digest", TypeKind: reflect.String, EnvVarName: "KBC_TARGET_RESULT_DIGEST", Usage: "Digest result file path"},
+	})
+
+	newGenerateTekton := func(args ...string) (*GenerateTekton, error) {
+		generate := &cobra.Command{Use: "tekton"}
+		root.AddCommand(generate)
+		t.Cleanup(func() { root.RemoveCommand(generate) })
+		common.RegisterParameters(generate, GenerateTektonParamsConfig)
+		if err := generate.Flags().Parse(args); err != nil {
+			return nil, err
+		}
+		return NewGenerateTekton(generate)
+	}
+
+	t.Run("should generate Task", func(t *testing.T) {
+		generateTekton, err := newGenerateTekton("--command", "group target", "--image", "quay.io/org/kbc:v1")
+		g.Expect(err).ToNot(HaveOccurred())
+		out := &bytes.Buffer{}
+		generateTekton.Output = out
+
+		g.Expect(generateTekton.Run()).To(Succeed())
+		g.Expect(out.String()).To(Equal(`apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: target
+spec:
+  description: Does something
+  params:
+    - name: IMAGE
+      description: Image to use
+      type: string
+    - name: PLATFORM
+      description: 'Platform (default: linux/amd64)'
+      type: string
+      default: ""
+    - name: RETRIES
+      description: Retries
+      type: string
+      default: "0"
+    - name: TAGS
+      description: 'Tags (default: latest)'
+      type: array
+      default: []
+  results:
+    - name: DIGEST
+      description: Digest result file path
+  steps:
+    - name: target
+      image: quay.io/org/kbc:v1
+      command:
+        - kbc
+        - group
+        - target
+      env:
+        - name: KBC_TARGET_IMAGE
+          value: $(params.IMAGE)
+        - name: KBC_TARGET_PLATFORM
+          value: $(params.PLATFORM)
+        - name: KBC_TARGET_RESULT_DIGEST
+          value: $(results.DIGEST.path)
+      args:
+        - --retries=$(params.RETRIES)
+        - --tags
+        - $(params.TAGS[*])
+        - ;
+`))
+	})
+
+	t.Run("should not override config file by defaults of generated params", func(t *testing.T) {
+		generateTekton, err := newGenerateTekton("--command", "group target")
+		g.Expect(err).ToNot(HaveOccurred())
+		params, _, step := generateTekton.buildDefinition()
+
+		// Resolve the step as Tekton does with default values of the params
+		paramValues := map[string][]string{"IMAGE": {"quay.io/org/image"}}
+		for _, param := range params {
+			switch value := param.Default.(type) {
+			case string:
+				paramValues[param.Name] = []string{value}
+			case []string:
+				paramValues[param.Name] = value
+			}
+		}
+		paramRegex := regexp.MustCompile(`\$\(params\.([A-Z_]+)(\[\*\])?\)`)
+		resolve := func(arg string) []string {
+			if match := paramRegex.FindStringSubmatch(arg); match != nil && match[0] == arg && match[2] != "" {
+				return paramValues[match[1]]
+			}
+			return []string{paramRegex.ReplaceAllStringFunc(arg, func(ref string) string {
+				return strings.Join(paramValues[paramRegex.FindStringSubmatch(ref)[1]], " ")
+			})}
+		}
+		for _, env := range step.Env {
+			t.Setenv(env.Name, resolve(env.Value)[0])
+		}
+		argv := []string{"group", "target"}
+		for _, arg := range step.Args {
+			argv = append(argv, resolve(arg)...)
+		}
+
+		configPath := filepath.Join(t.TempDir(), "config.yaml")
+		g.Expect(os.WriteFile(configPath, []byte("group target:\n  platform: linux/arm64\n  tags: [v1, v2]\n  retries: 0\n"), 0644)).To(Succeed())
+		config, err := common.LoadConfigFile(configPath)
+		g.Expect(err).ToNot(HaveOccurred())
+		common.SetConfigFile(config)
+		t.Cleanup(func() { common.SetConfigFile(nil) })
+
+		g.Expect(target.Flags().Parse(common.ExpandArrayParameters(argv)[2:])).To(Succeed())
+		targetParams := &struct {
+			Image    string   `paramName:"image"`
+			Tags     []string `paramName:"tags"`
+			Retries  int      `paramName:"retries"`
+			Platform string   `paramName:"platform"`
+			Digest   string   `paramName:"result-digest"`
+		}{}
+		g.Expect(common.ParseParameters(target, common.CommandParameters(target), targetParams)).To(Succeed())
+		g.Expect(targetParams.Image).To(Equal("quay.io/org/image"))
+		g.Expect(targetParams.Platform).To(Equal("linux/arm64"))
+		g.Expect(targetParams.Tags).To(Equal([]string{"v1", "v2"}))
+	})
+
+	t.Run("should generate StepAction", func(t *testing.T) {
+		generateTekton, err := newGenerateTekton("--command", "group target", "--kind", "StepAction", "--name", "do-target")
+		g.Expect(err).ToNot(HaveOccurred())
+		out := &bytes.Buffer{}
+		generateTekton.Output = out
+
+		g.Expect(generateTekton.Run()).To(Succeed())
+		g.Expect(out.String()).To(HavePrefix("apiVersion: tekton.dev/v1beta1\nkind: StepAction\nmetadata:\n  name: do-target\n"))
+		g.Expect(out.String()).To(ContainSubstring("\n  image: quay.io/konflux-ci/konflux-build-cli:latest\n"))
+		g.Expect(out.String()).To(ContainSubstring("value: $(step.results.DIGEST.path)\n"))
+		g.Expect(out.String()).ToNot(ContainSubstring("steps:"))
+	})
+
+	t.Run("should describe results registered from results struct", func(t *testing.T) {
+		withResults := &cobra.Command{Use: "with-results"}
+		group.AddCommand(withResults)
+		t.Cleanup(func() { group.RemoveCommand(withResults) })
+		common.RegisterParameters(withResults, map[string]common.Parameter{
+			"image": {Name: "image", TypeKind: reflect.String, EnvVarName: "KBC_WITH_RESULTS_IMAGE", Usage: "Image to use", Required: true},
+		})
+		common.RegisterResults(withResults, struct {
+			Tags []string `result:"tags" description:"Created tags"`
+		}{}, "KBC_WITH_RESULTS_RESULT_")
+
+		generateTekton, err := newGenerateTekton("--command", "group with-results")
+		g.Expect(err).ToNot(HaveOccurred())
+		out := &bytes.Buffer{}
+		generateTekton.Output = out
+
+		g.Expect(generateTekton.Run()).To(Succeed())
+		g.Expect(out.String()).To(ContainSubstring("  results:\n    - name: TAGS\n      description: Created tags\n      type: array\n"))
+	})
+
+	t.Run("should fail on unknown command", func(t *testing.T) {
+		_, err := newGenerateTekton("--command", "group unknown")
+		g.Expect(err).To(MatchError("unknown command 'group unknown'"))
+	})
+
+	t.Run("should fail on unknown kind", func(t *testing.T) {
+		_, err := newGenerateTekton("--command", "group target", "--kind", "Pipeline")
+		g.Expect(err).To(MatchError(ContainSubstring("must be one of: Task, StepAction")))
+	})
+}
@@ -11,6 +11,7 @@ var _ common.ResultsWriterInterface = &mockResultsWriter{}
 type mockResultsWriter struct {
 	WriteResultStringFunc func(result, path string) error
 	CreateResultJsonFunc  func(result any) (string, error)
+	WriteResultsFunc      func(results any) error
 
 	// Result file path => result data
 	WrittenResults map[string]string
@@ -36,3 +37,10 @@ func (m *mockResultsWriter) WriteResultString(result, path string) error {
 	m.WrittenResults[path] = result
 	return nil
 }
+
+func (m *mockResultsWriter) WriteResults(results any) error {
+	if m.WriteResultsFunc != nil {
+		return m.WriteResultsFunc(results)
+	}
+	return nil
+}
@@ -1,9 +1,14 @@
 package common
 
 import (
+	"encoding/json"
+	"fmt"
+	"regexp"
 	"strings"
+	"unicode"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var arrayParamsInCommands = map[*cobra.Command][]string{}
@@ -21,17 +26,18 @@ func recordArrayParamForCommand(cmd *cobra.Command, paramName string) {
 func buildArrayParamsData() map[string][]string {
 	arrayParams := map[string][]string{}
 	for cmd, params := range arrayParamsInCommands {
-		commandPath := cmd.CommandPath()
-		// Remove the root command from the command path
-		firstSpaceIndex := strings.Index(commandPath, " ")
-		if firstSpaceIndex > 0 {
-			commandPath = commandPath[firstSpaceIndex+1:]
-		}
-		arrayParams[commandPath] = params
+		arrayParams[CommandPathWithoutRoot(cmd)] = params
 	}
 	return arrayParams
 }
 
+// ArrayEndMarker ends values of an array parameter explicitly, then all arguments before it are values,
+// even if they start with a dash, e.g. "--array -v1 v2 ; --some-arg".
+const ArrayEndMarker = ";"
+
+// negativeNumberRegex matches values which start with a dash, but are not flags.
+var negativeNumberRegex = regexp.MustCompile(`^-[0-9]+(\.[0-9]+)?$`)
+
 // expandArrayParameters is a workaround for missing pflag ability to parse parameters array separated by spaces.
 // We need to process parameters like:
 // cli --array v1 v2 v3 --some-arg
@@ -40,6 +46,8 @@ func buildArrayParamsData() map[string][]string {
 // or comma separated values like:
 // cli --array v1,v2,v3 --some-arg
 // This function expands array parameters, so "--array v1 v2 v3" becomes "--array v1 --array v2 --array v3"
+// Values end at the next argument starting with a dash, except negative numbers, or at ArrayEndMarker.
+// A single value starting with a dash can be given as "--array=-v1".
 func ExpandArrayParameters(argv []string) []string {
 	out := make([]string, 0, len(argv))
 
@@ -59,6 +67,7 @@ func ExpandArrayParameters(argv []string) []string {
 	for _, arrayParam := range arrayParams[commandPath] {
 		multiFlags[arrayParam] = true
 	}
+	otherFlags := otherCommandFlags(commandPath, multiFlags)
 
 	for i := 0; i < len(argv); i++ {
 		arg := argv[i]
@@ -75,14 +84,12 @@ func ExpandArrayParameters(argv []string) []string {
 			flag := parts[0]
 			if multiFlags[flag] {
 				out = append(out, flag, parts[1])
-				i++
-				for i < len(argv) && argv[i] != "--" && !strings.HasPrefix(argv[i], "-") {
-					out = append(out, flag, argv[i])
-					i++
+				values, next := collectArrayValues(argv, i+1, multiFlags, otherFlags)
+				for _, value := range values {
+					out = append(out, flag, value)
 				}
-				// Now i points to the next parameter, if any.
 				// Step back, because the for loop will increment i
-				i--
+				i = next - 1
 				continue
 			}
 			// Just regular arg=value parameter
@@ -92,15 +99,12 @@ func ExpandArrayParameters(argv []string) []string {
 
 		// If this arg is an array, duplicate the arg before each array element.
 		if multiFlags[arg] {
-			j := i + 1
-			for j < len(argv) && argv[j] != "--" && !strings.HasPrefix(argv[j], "-") {
-				out = append(out, arg, argv[j])
-				j++
+			values, next := collectArrayValues(argv, i+1, multiFlags, otherFlags)
+			for _, value := range values {
+				out = append(out, arg, value)
 			}
-			// Now j points to the next parameter, if any.
-			i = j
 			// Step back, because the for loop will increment i
-			i--
+			i = next - 1
 			continue
 		}
 
@@ -108,3 +112,108 @@ func ExpandArrayParameters(argv []string) []string {
 	}
 	return out
 }
+
+// otherCommandFlags returns flags of the command other than the array ones, including inherited flags,
+// both in --name and -shorthand form.
+func otherCommandFlags(commandPath string, multiFlags map[string]bool) map[string]bool {
+	otherFlags := map[string]bool{}
+	addFlag := func(flag *pflag.Flag) {
+		for _, name := range []string{"--" + flag.Name, "-" + flag.Shorthand} {
+			if name != "-" && !multiFlags[name] {
+				otherFlags[name] = true
+			}
+		}
+	}
+	for cmd := range arrayParamsInCommands {
+		if CommandPathWithoutRoot(cmd) == commandPath {
+			cmd.Flags().VisitAll(addFlag)
+			cmd.InheritedFlags().VisitAll(addFlag)
+		}
+	}
+	return otherFlags
+}
+
+// collectArrayValues returns values of an array parameter starting at argv[start]
+// and index of the next argument to process.
+func collectArrayValues(argv []string, start int, multiFlags, otherFlags map[string]bool) ([]string, int) {
+	// Look for the end marker before the next flag, which is known to be another parameter.
+	for j := start; j < len(argv) && argv[j] != "--"; j++ {
+		if flag := strings.SplitN(argv[j], "=", 2)[0]; multiFlags[flag] || otherFlags[flag] {
+			break
+		}
+		if argv[j] == ArrayEndMarker {
+			return argv[start:j], j + 1
+		}
+	}
+
+	j := start
+	for j < len(argv) && argv[j] != "--" && (!strings.HasPrefix(argv[j], "-") || negativeNumberRegex.MatchString(argv[j])) {
+		j++
+	}
+	return argv[start:j], j
+}
+
+// splitArrayValue splits array parameter value given in an environment variable.
+// The value is either a JSON array of strings, e.g. ["v 1", "v2"], or shell-like words, e.g. 'v 1' v2,
+// where single quotes keep the content as is, double quotes and backslash escape the next character.
+// Values without quotes and backslashes are space separated as before.
+func splitArrayValue(value string) ([]string, error) {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "[") {
+		values := []string{}
+		if err := json.Unmarshal([]byte(trimmed), &values); err != nil {
+			return nil, fmt.Errorf("invalid JSON array of strings: %w", err)
+		}
+		return values, nil
+	}
+
+	values := []string{}
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, c := range value {
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case unicode.IsSpace(c):
+			if inWord {
+				values = append(values, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("unterminated backslash escape")
+	}
+	if inWord {
+		values = append(values, word.String())
+	}
+	return values, nil
+}
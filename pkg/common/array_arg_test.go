@@ -154,6 +154,69 @@ func TestExpandArrayParameters(t *testing.T) {
 		g.Expect(result).To(Equal(expected))
 	})
 
+	t.Run("should consume negative numbers as array values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		setupTestCommands()
+
+		argv := []string{"subcmd", "--array-param", "-1", "2", "-3.5", "--other-flag"}
+		result := ExpandArrayParameters(argv)
+
+		expected := []string{"subcmd", "--array-param", "-1", "--array-param", "2", "--array-param", "-3.5", "--other-flag"}
+		g.Expect(result).To(Equal(expected))
+	})
+
+	t.Run("should handle value starting with dash in equals syntax", func(t *testing.T) {
+		g := NewWithT(t)
+
+		setupTestCommands()
+
+		argv := []string{"subcmd", "--array-param=-v1", "--other-flag"}
+		result := ExpandArrayParameters(argv)
+
+		expected := []string{"subcmd", "--array-param", "-v1", "--other-flag"}
+		g.Expect(result).To(Equal(expected))
+	})
+
+	t.Run("should consume all values until end marker", func(t *testing.T) {
+		g := NewWithT(t)
+
+		setupTestCommands()
+
+		argv := []string{"subcmd", "--array-param", "-v1", "v 2", ";", "--other-flag", "-a=-v3", "--v4", ";"}
+		result := ExpandArrayParameters(argv)
+
+		expected := []string{"subcmd", "--array-param", "-v1", "--array-param", "v 2", "--other-flag", "-a", "-v3", "-a", "--v4"}
+		g.Expect(result).To(Equal(expected))
+	})
+
+	t.Run("should not use end marker of next array parameter", func(t *testing.T) {
+		g := NewWithT(t)
+
+		setupTestCommands()
+
+		argv := []string{"subcmd", "--array-param", "v1", "--multi", "-m1", ";"}
+		result := ExpandArrayParameters(argv)
+
+		expected := []string{"subcmd", "--array-param", "v1", "--multi", "-m1"}
+		g.Expect(result).To(Equal(expected))
+	})
+
+	t.Run("should not use end marker after other known flag", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, subCmd := setupTestCommands()
+		subCmd.Flags().StringP("other-flag", "o", "", "")
+
+		for _, otherFlag := range [][]string{{"--other-flag", "x"}, {"--other-flag=x"}, {"-o", "x"}} {
+			argv := append(append([]string{"subcmd", "--array-param", "v1"}, otherFlag...), ";")
+			result := ExpandArrayParameters(argv)
+
+			expected := append(append([]string{"subcmd", "--array-param", "v1"}, otherFlag...), ";")
+			g.Expect(result).To(Equal(expected))
+		}
+	})
+
 	// TODO improve array parameters expansion.
 	// Note, that:
 	// - persistant flags could have a value unless the flag is of boolen type.
@@ -236,3 +299,38 @@ func TestBuildArrayParamsData(t *testing.T) {
 		g.Expect(data["sub nested"]).To(ContainElement("--nested-array"))
 	})
 }
+
+func TestSplitArrayValue(t *testing.T) {
+	testCases := []struct {
+		name          string
+		value         string
+		expected      []string
+		expectedError string
+	}{
+		{name: "space separated", value: " v1  v2\tv3 ", expected: []string{"v1", "v2", "v3"}},
+		{name: "empty", value: "", expected: []string{}},
+		{name: "JSON array", value: ` ["v 1", "-v2", ""]`, expected: []string{"v 1", "-v2", ""}},
+		{name: "empty JSON array", value: "[]", expected: []string{}},
+		{name: "invalid JSON array", value: `["v1", 2]`, expectedError: "invalid JSON array of strings"},
+		{name: "single quotes", value: `'v 1' 'it"s'`, expected: []string{"v 1", `it"s`}},
+		{name: "double quotes", value: `"v 1" "a \"b\"" ""`, expected: []string{"v 1", `a "b"`, ""}},
+		{name: "backslash", value: `v\ 1 v\\2`, expected: []string{"v 1", `v\2`}},
+		{name: "quoted part of word", value: `key='v 1'`, expected: []string{"key=v 1"}},
+		{name: "unterminated quote", value: `"v1`, expectedError: `unterminated " quote`},
+		{name: "unterminated escape", value: `v1\`, expectedError: "unterminated backslash escape"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			values, err := splitArrayValue(tc.value)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedError)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(values).To(Equal(tc.expected))
+		})
+	}
+}
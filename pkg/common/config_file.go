@@ -0,0 +1,173 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// registeredParameters holds parameters of each command, see RegisterParameters.
+var registeredParameters = map[*cobra.Command]map[string]Parameter{}
+
+// CommandParameters returns parameters registered for the command.
+func CommandParameters(cmd *cobra.Command) map[string]Parameter {
+	return registeredParameters[cmd]
+}
+
+// CommandsWithParameters returns all commands with registered parameters, sorted by command path.
+func CommandsWithParameters() []*cobra.Command {
+	commands := []*cobra.Command{}
+	for cmd := range registeredParameters {
+		commands = append(commands, cmd)
+	}
+	slices.SortFunc(commands, func(a, b *cobra.Command) int {
+		return strings.Compare(a.CommandPath(), b.CommandPath())
+	})
+	return commands
+}
+
+// CommandPathWithoutRoot returns the command path without the root command name, e.g. "image apply-tags".
+func CommandPathWithoutRoot(cmd *cobra.Command) string {
+	commandPath := cmd.CommandPath()
+	firstSpaceIndex := strings.Index(commandPath, " ")
+	if firstSpaceIndex > 0 {
+		commandPath = commandPath[firstSpaceIndex+1:]
+	}
+	return commandPath
+}
+
+// ConfigFile holds parameter values read from a YAML or JSON file, keyed by command path and parameter name:
+//
+//	image apply-tags:
+//	  image-url: quay.io/org/app
+//	  tags: [latest, v1]
+//
+// The values are used for parameters given neither in the command line nor in their environment variables.
+type ConfigFile struct {
+	Path string
+	// commands hold YAML nodes of parameter values, so scalars keep their literal text, e.g. tag 1.10.
+	commands map[string]map[string]yaml.Node
+}
+
+// configFile is the configuration file of the current CLI run, nil if none.
+var configFile *ConfigFile
+
+// SetConfigFile makes parameters fall back to the values in the configuration file, nil disables it.
+func SetConfigFile(c *ConfigFile) {
+	configFile = c
+}
+
+// ConfigFilePath returns path of the configuration file in use, empty if none.
+func ConfigFilePath() string {
+	if configFile == nil {
+		return ""
+	}
+	return configFile.Path
+}
+
+// LoadConfigFile reads the configuration file. JSON is accepted, as it's a subset of YAML.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	commands := map[string]map[string]yaml.Node{}
+	if err := yaml.Unmarshal(data, &commands); err != nil {
+		return nil, fmt.Errorf("failed to parse config file '%s', expected parameters by command path: %w", path, err)
+	}
+	return &ConfigFile{Path: path, commands: commands}, nil
+}
+
+// Validate returns error for commands or parameters in the file which don't exist, likely typos.
+// It must be called after all commands are registered.
+func (c *ConfigFile) Validate() error {
+	knownParameters := map[string]map[string]Parameter{}
+	for cmd, params := range registeredParameters {
+		knownParameters[CommandPathWithoutRoot(cmd)] = params
+	}
+
+	var errs []error
+	for commandPath, values := range c.commands {
+		params, ok := knownParameters[commandPath]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown command '%s' in config file '%s'", commandPath, c.Path))
+			continue
+		}
+		for name := range values {
+			if _, ok := params[name]; !ok {
+				errs = append(errs, fmt.Errorf("unknown parameter '%s' of '%s' command in config file '%s'", name, commandPath, c.Path))
+			}
+		}
+	}
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return errors.Join(errs...)
+}
+
+// lookup returns the parameter value from the file, nil if it's not set.
+// Values are taken as written, e.g. 1.10 is "1.10", not a number.
+func (c *ConfigFile) lookup(cmd *cobra.Command, p Parameter) (*ParameterValue, error) {
+	if c == nil {
+		return nil, nil
+	}
+	raw, ok := c.commands[CommandPathWithoutRoot(cmd)][p.Name]
+	if !ok {
+		return nil, nil
+	}
+	node := resolveAlias(&raw)
+	if node.Tag == "!!null" {
+		return nil, nil
+	}
+
+	value := &ParameterValue{Source: ParameterSourceConfig}
+	switch {
+	case p.TypeKind == reflect.Map && node.Kind == yaml.MappingNode:
+		items := map[string]string{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, item := resolveAlias(node.Content[i]), resolveAlias(node.Content[i+1])
+			if key.Kind != yaml.ScalarNode || item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("parameter '%s' in config file '%s' must be a map of single values", p.Name, c.Path)
+			}
+			items[key.Value] = item.Value
+		}
+		value.Values = []string{}
+		for _, key := range slices.Sorted(maps.Keys(items)) {
+			value.Values = append(value.Values, key+"="+items[key])
+		}
+		return value, nil
+	case isMultiValueKind(p.TypeKind) && node.Kind == yaml.SequenceNode:
+		value.Values = []string{}
+		for _, item := range node.Content {
+			if item = resolveAlias(item); item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("parameter '%s' in config file '%s' must be a list of single values", p.Name, c.Path)
+			}
+			value.Values = append(value.Values, item.Value)
+		}
+		return value, nil
+	case node.Kind != yaml.ScalarNode:
+		if isMultiValueKind(p.TypeKind) {
+			return nil, fmt.Errorf("parameter '%s' in config file '%s' must be a list", p.Name, c.Path)
+		}
+		return nil, fmt.Errorf("parameter '%s' in config file '%s' must be a single value", p.Name, c.Path)
+	case isMultiValueKind(p.TypeKind):
+		value.Values = strings.Fields(node.Value)
+		return value, nil
+	default:
+		value.Value = node.Value
+		return value, nil
+	}
+}
+
+// resolveAlias returns the node an alias points to, e.g. *tags for &tags [a, b].
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
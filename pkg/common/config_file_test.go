@@ -0,0 +1,182 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+// setupConfigFile writes the config file and makes parameters use it for the rest of the test.
+func setupConfigFile(t *testing.T, name, content string) *ConfigFile {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetConfigFile(c)
+	t.Cleanup(func() { SetConfigFile(nil) })
+	return c
+}
+
+// newConfigTestCommand returns "root group sub" command with registered parameters.
+func newConfigTestCommand(paramsConfig map[string]Parameter) *cobra.Command {
+	root := &cobra.Command{Use: "root"}
+	group := &cobra.Command{Use: "group"}
+	sub := &cobra.Command{Use: "sub"}
+	root.AddCommand(group)
+	group.AddCommand(sub)
+	RegisterParameters(sub, paramsConfig)
+	return sub
+}
+
+func TestConfigFile(t *testing.T) {
+	type ConfigTestParams struct {
+		StringParam string   `paramName:"string-param"`
+		IntParam    int      `paramName:"int-param"`
+		BoolParam   bool     `paramName:"bool-param"`
+		ArrayParam  []string `paramName:"array-param"`
+	}
+	paramsConfig := map[string]Parameter{
+		"string-param": {Name: "string-param", TypeKind: reflect.String, EnvVarName: "KBC_TEST_CONFIG_STRING", DefaultValue: "default", Required: true},
+		"int-param":    {Name: "int-param", TypeKind: reflect.Int, DefaultValue: "1"},
+		"bool-param":   {Name: "bool-param", TypeKind: reflect.Bool},
+		"array-param":  {Name: "array-param", TypeKind: reflect.Array, EnvVarName: "KBC_TEST_CONFIG_ARRAY"},
+	}
+
+	t.Run("should use values from YAML config file", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newConfigTestCommand(paramsConfig)
+		setupConfigFile(t, "config.yaml", `
+group sub:
+  string-param: from-config
+  int-param: 5
+  bool-param: true
+  array-param: [a, b, 3]
+`)
+
+		params := &ConfigTestParams{}
+		g.Expect(ParseParameters(cmd, paramsConfig, params)).To(Succeed())
+		g.Expect(params).To(Equal(&ConfigTestParams{
+			StringParam: "from-config",
+			IntParam:    5,
+			BoolParam:   true,
+			ArrayParam:  []string{"a", "b", "3"},
+		}))
+	})
+
+	t.Run("should keep literal text of scalar values", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newConfigTestCommand(paramsConfig)
+		setupConfigFile(t, "config.yaml", `
+group sub:
+  string-param: 1.0
+  array-param: [1.10, 1.0, 0x1F]
+`)
+
+		params := &ConfigTestParams{}
+		g.Expect(ParseParameters(cmd, paramsConfig, params)).To(Succeed())
+		g.Expect(params.StringParam).To(Equal("1.0"))
+		g.Expect(params.ArrayParam).To(Equal([]string{"1.10", "1.0", "0x1F"}))
+	})
+
+	t.Run("should use values from JSON config file", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newConfigTestCommand(paramsConfig)
+		setupConfigFile(t, "config.json", `{"group sub": {"string-param": "from-json", "array-param": "a b"}}`)
+
+		params := &ConfigTestParams{}
+		g.Expect(ParseParameters(cmd, paramsConfig, params)).To(Succeed())
+		g.Expect(params.StringParam).To(Equal("from-json"))
+		g.Expect(params.IntParam).To(Equal(1))
+		g.Expect(params.ArrayParam).To(Equal([]string{"a", "b"}))
+	})
+
+	t.Run("should prefer flags and env vars over config file", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newConfigTestCommand(paramsConfig)
+		setupConfigFile(t, "config.yaml", `
+group sub:
+  string-param: from-config
+  int-param: 5
+  array-param: [a]
+`)
+		t.Setenv("KBC_TEST_CONFIG_STRING", "from-env")
+		g.Expect(cmd.Flags().Set("int-param", "7")).To(Succeed())
+
+		params := &ConfigTestParams{}
+		g.Expect(ParseParameters(cmd, paramsConfig, params)).To(Succeed())
+		g.Expect(params.StringParam).To(Equal("from-env"))
+		g.Expect(params.IntParam).To(Equal(7))
+		g.Expect(params.ArrayParam).To(Equal([]string{"a"}))
+
+		value, err := ResolveParameter(cmd, paramsConfig["string-param"])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(value.Source).To(Equal(ParameterSourceEnv))
+		value, err = ResolveParameter(cmd, paramsConfig["int-param"])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(value.Source).To(Equal(ParameterSourceFlag))
+		value, err = ResolveParameter(cmd, paramsConfig["array-param"])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(value.Source).To(Equal(ParameterSourceConfig))
+		value, err = ResolveParameter(cmd, paramsConfig["bool-param"])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(value.Source).To(Equal(ParameterSourceDefault))
+	})
+
+	t.Run("should fail on required parameter missing also in config file", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newConfigTestCommand(paramsConfig)
+		setupConfigFile(t, "config.yaml", "other sub:\n  string-param: value\n")
+
+		err := ParseParameters(cmd, paramsConfig, &ConfigTestParams{})
+		g.Expect(err).To(MatchError("required parameter 'string-param' is not set"))
+	})
+
+	t.Run("should fail on invalid values in config file", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newConfigTestCommand(paramsConfig)
+		setupConfigFile(t, "config.yaml", "group sub:\n  string-param: [a, b]\n")
+		err := ParseParameters(cmd, paramsConfig, &ConfigTestParams{})
+		g.Expect(err).To(MatchError(ContainSubstring("parameter 'string-param' in config file")))
+
+		setupConfigFile(t, "config.yaml", "group sub:\n  string-param: a\n  int-param: five\n")
+		err = ParseParameters(cmd, paramsConfig, &ConfigTestParams{})
+		g.Expect(err).To(MatchError(ContainSubstring("invalid value 'five' of parameter 'int-param' from config")))
+	})
+
+	t.Run("should report unknown commands and parameters", func(t *testing.T) {
+		g := NewWithT(t)
+		newConfigTestCommand(paramsConfig)
+		c := setupConfigFile(t, "config.yaml", `
+group sub:
+  string-param: value
+  strnig-param: typo
+group other:
+  any: value
+`)
+
+		err := c.Validate()
+		g.Expect(err).To(MatchError(ContainSubstring("unknown command 'group other'")))
+		g.Expect(err).To(MatchError(ContainSubstring("unknown parameter 'strnig-param' of 'group sub' command")))
+		g.Expect(err).ToNot(MatchError(ContainSubstring("'string-param'")))
+	})
+
+	t.Run("should fail on malformed config file", func(t *testing.T) {
+		g := NewWithT(t)
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		g.Expect(os.WriteFile(path, []byte("- a\n- b\n"), 0644)).To(Succeed())
+
+		_, err := LoadConfigFile(path)
+		g.Expect(err).To(MatchError(ContainSubstring("failed to parse config file")))
+
+		_, err = LoadConfigFile(filepath.Join(t.TempDir(), "missing.yaml"))
+		g.Expect(err).To(MatchError(ContainSubstring("failed to read config file")))
+	})
+}
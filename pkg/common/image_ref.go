@@ -1,6 +1,10 @@
 package common
 
 import (
+	// Register hash functions, go-digest can't validate digests without them.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
 	"github.com/containers/image/v5/docker/reference"
 	go_digest "github.com/opencontainers/go-digest"
 )
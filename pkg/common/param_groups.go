@@ -0,0 +1,115 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ParameterGroupKind is the constraint put on parameters of a group.
+type ParameterGroupKind string
+
+const (
+	// ParameterGroupMutuallyExclusive allows at most one parameter of the group to be set.
+	ParameterGroupMutuallyExclusive ParameterGroupKind = "mutually exclusive"
+	// ParameterGroupRequiredTogether requires either all or none parameters of the group to be set.
+	ParameterGroupRequiredTogether ParameterGroupKind = "required together"
+	// ParameterGroupOneRequired requires at least one parameter of the group to be set.
+	ParameterGroupOneRequired ParameterGroupKind = "one required"
+)
+
+// ParameterGroup is a constraint on several parameters of a command, see RegisterParameters.
+// A parameter is set if its value comes from a flag, an environment variable or the config file, not from the default.
+type ParameterGroup struct {
+	Kind  ParameterGroupKind
+	Names []string
+}
+
+// MutuallyExclusive declares parameters that cannot be used together.
+func MutuallyExclusive(names ...string) ParameterGroup {
+	return ParameterGroup{Kind: ParameterGroupMutuallyExclusive, Names: names}
+}
+
+// RequiredTogether declares parameters that make sense only together.
+func RequiredTogether(names ...string) ParameterGroup {
+	return ParameterGroup{Kind: ParameterGroupRequiredTogether, Names: names}
+}
+
+// OneRequired declares parameters of which at least one must be given.
+func OneRequired(names ...string) ParameterGroup {
+	return ParameterGroup{Kind: ParameterGroupOneRequired, Names: names}
+}
+
+// registeredParameterGroups holds parameter groups of each command, see RegisterParameters.
+var registeredParameterGroups = map[*cobra.Command][]ParameterGroup{}
+
+// validateParameterGroups panics if a group is invalid, e.g. refers to unknown parameter.
+func validateParameterGroups(paramsConfig map[string]Parameter, groups []ParameterGroup) {
+	for _, group := range groups {
+		switch group.Kind {
+		case ParameterGroupMutuallyExclusive, ParameterGroupRequiredTogether, ParameterGroupOneRequired:
+		default:
+			panic(fmt.Sprintf("unknown parameter group kind '%s'", group.Kind))
+		}
+		if len(group.Names) < 2 {
+			panic(fmt.Sprintf("%s parameter group must have at least two parameters", group.Kind))
+		}
+		for _, name := range group.Names {
+			if _, ok := paramsConfig[name]; !ok {
+				panic(fmt.Sprintf("unknown parameter '%s' in %s parameter group", name, group.Kind))
+			}
+		}
+	}
+}
+
+// CommandParameterGroups returns parameter groups registered for the command.
+func CommandParameterGroups(cmd *cobra.Command) []ParameterGroup {
+	return registeredParameterGroups[cmd]
+}
+
+// checkParameterGroups returns errors for violated parameter group constraints.
+// sources holds sources of resolved parameters, parameters failed to resolve are missing.
+func checkParameterGroups(groups []ParameterGroup, paramsConfig map[string]Parameter, sources map[string]ParameterSource) []error {
+	var errs []error
+	for _, group := range groups {
+		var set, unset []string
+		for _, name := range group.Names {
+			source, resolved := sources[name]
+			if !resolved {
+				// Already reported by ParseParameters
+				continue
+			}
+			if source == ParameterSourceDefault {
+				unset = append(unset, describeParameter(paramsConfig[name]))
+			} else {
+				set = append(set, fmt.Sprintf("%s from %s", describeParameter(paramsConfig[name]), source))
+			}
+		}
+
+		all := describeParameters(paramsConfig, group.Names)
+		switch group.Kind {
+		case ParameterGroupMutuallyExclusive:
+			if len(set) > 1 {
+				errs = append(errs, fmt.Errorf("only one of parameters %s can be set, got %s", all, strings.Join(set, ", ")))
+			}
+		case ParameterGroupRequiredTogether:
+			if len(set) > 0 && len(unset) > 0 {
+				errs = append(errs, fmt.Errorf("parameters %s must be set together, missing %s", all, strings.Join(unset, ", ")))
+			}
+		case ParameterGroupOneRequired:
+			if len(set) == 0 && len(unset) == len(group.Names) {
+				errs = append(errs, fmt.Errorf("at least one of parameters %s must be set", all))
+			}
+		}
+	}
+	return errs
+}
+
+func describeParameters(paramsConfig map[string]Parameter, names []string) string {
+	described := make([]string, len(names))
+	for i, name := range names {
+		described[i] = describeParameter(paramsConfig[name])
+	}
+	return strings.Join(described, ", ")
+}
@@ -0,0 +1,136 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+type groupedParams struct {
+	Tags     []string `paramName:"tags"`
+	Label    string   `paramName:"label"`
+	User     string   `paramName:"user"`
+	Password string   `paramName:"password"`
+	Verbose  bool     `paramName:"verbose"`
+}
+
+var groupedParamsConfig = map[string]Parameter{
+	"tags":     {Name: "tags", TypeKind: reflect.Array, EnvVarName: "KBC_TEST_TAGS"},
+	"label":    {Name: "label", TypeKind: reflect.String, EnvVarName: "KBC_TEST_LABEL"},
+	"user":     {Name: "user", TypeKind: reflect.String},
+	"password": {Name: "password", TypeKind: reflect.String, EnvVarName: "KBC_TEST_PASSWORD"},
+	"verbose":  {Name: "verbose", TypeKind: reflect.Bool, DefaultValue: "false"},
+}
+
+func newGroupedCommand(t *testing.T, groups ...ParameterGroup) *cobra.Command {
+	cmd := &cobra.Command{Use: "grouped"}
+	t.Cleanup(func() {
+		delete(registeredParameters, cmd)
+		delete(registeredParameterGroups, cmd)
+	})
+	RegisterParameters(cmd, groupedParamsConfig, groups...)
+	return cmd
+}
+
+func TestParseParameters_Groups(t *testing.T) {
+	testCases := []struct {
+		name          string
+		groups        []ParameterGroup
+		args          []string
+		env           map[string]string
+		expectedError string
+	}{
+		{
+			name:   "should allow one of mutually exclusive parameters",
+			groups: []ParameterGroup{MutuallyExclusive("tags", "label")},
+			args:   []string{"--tags", "v1"},
+		},
+		{
+			name:          "should reject mutually exclusive parameters from flag and env",
+			groups:        []ParameterGroup{MutuallyExclusive("tags", "label")},
+			args:          []string{"--tags", "v1"},
+			env:           map[string]string{"KBC_TEST_LABEL": "tags"},
+			expectedError: "only one of parameters --tags (KBC_TEST_TAGS), --label (KBC_TEST_LABEL) can be set, got --tags (KBC_TEST_TAGS) from flag, --label (KBC_TEST_LABEL) from env",
+		},
+		{
+			name:   "should allow none of required together parameters",
+			groups: []ParameterGroup{RequiredTogether("user", "password")},
+		},
+		{
+			name:   "should allow all of required together parameters",
+			groups: []ParameterGroup{RequiredTogether("user", "password")},
+			args:   []string{"--user", "admin"},
+			env:    map[string]string{"KBC_TEST_PASSWORD": "secret"},
+		},
+		{
+			name:          "should reject part of required together parameters",
+			groups:        []ParameterGroup{RequiredTogether("user", "password")},
+			args:          []string{"--user", "admin"},
+			expectedError: "parameters --user, --password (KBC_TEST_PASSWORD) must be set together, missing --password (KBC_TEST_PASSWORD)",
+		},
+		{
+			name:   "should accept one required parameter from env",
+			groups: []ParameterGroup{OneRequired("tags", "label")},
+			env:    map[string]string{"KBC_TEST_TAGS": "v1 v2"},
+		},
+		{
+			name:          "should reject none of one required parameters",
+			groups:        []ParameterGroup{OneRequired("tags", "label")},
+			expectedError: "at least one of parameters --tags (KBC_TEST_TAGS), --label (KBC_TEST_LABEL) must be set",
+		},
+		{
+			name:   "should count explicitly given default value as set",
+			groups: []ParameterGroup{MutuallyExclusive("verbose", "label")},
+			args:   []string{"--verbose=false", "--label", "tags"},
+			expectedError: "only one of parameters --verbose, --label (KBC_TEST_LABEL) can be set, " +
+				"got --verbose from flag, --label (KBC_TEST_LABEL) from flag",
+		},
+		{
+			name:   "should report all violated groups",
+			groups: []ParameterGroup{OneRequired("tags", "label"), RequiredTogether("user", "password")},
+			args:   []string{"--password", "secret"},
+			expectedError: "at least one of parameters --tags (KBC_TEST_TAGS), --label (KBC_TEST_LABEL) must be set; " +
+				"parameters --user, --password (KBC_TEST_PASSWORD) must be set together, missing --user",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+			cmd := newGroupedCommand(t, tc.groups...)
+			g.Expect(cmd.Flags().Parse(tc.args)).To(Succeed())
+
+			err := ParseParameters(cmd, groupedParamsConfig, &groupedParams{})
+			if tc.expectedError == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			}
+		})
+	}
+}
+
+func TestParameterGroups(t *testing.T) {
+	t.Run("should return registered groups", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newGroupedCommand(t, OneRequired("tags", "label"), RequiredTogether("user", "password"))
+
+		g.Expect(CommandParameterGroups(cmd)).To(Equal([]ParameterGroup{OneRequired("tags", "label"), RequiredTogether("user", "password")}))
+	})
+
+	t.Run("should panic on invalid groups", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(func() { newGroupedCommand(t, OneRequired("tags", "unknown")) }).
+			To(PanicWith("unknown parameter 'unknown' in one required parameter group"))
+		g.Expect(func() { newGroupedCommand(t, MutuallyExclusive("tags")) }).
+			To(PanicWith("mutually exclusive parameter group must have at least two parameters"))
+		g.Expect(func() {
+			newGroupedCommand(t, ParameterGroup{Kind: "any", Names: []string{"tags", "label"}})
+		}).To(PanicWith("unknown parameter group kind 'any'"))
+	})
+}
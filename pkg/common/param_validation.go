@@ -0,0 +1,178 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validator is a constraint on parameter values, see Parameter.Validators.
+type Validator struct {
+	// Description tells what a valid value is, e.g. "must be one of: text, json".
+	// It's used in error messages and documentation.
+	Description string
+	// Check returns true if the value is valid.
+	Check func(value string) bool
+}
+
+// ValidatePattern requires values to match the regular expression.
+func ValidatePattern(pattern string) Validator {
+	regex := regexp.MustCompile(pattern)
+	return Validator{
+		Description: fmt.Sprintf("must match %s", pattern),
+		Check:       regex.MatchString,
+	}
+}
+
+// ValidateOneOf requires values to be one of the given ones.
+func ValidateOneOf(values ...string) Validator {
+	return Validator{
+		Description: fmt.Sprintf("must be one of: %s", strings.Join(values, ", ")),
+		Check: func(value string) bool {
+			for _, v := range values {
+				if value == v {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// ValidateMin requires numeric values to be at least min.
+func ValidateMin(min float64) Validator {
+	return Validator{
+		Description: fmt.Sprintf("must be at least %v", min),
+		Check: func(value string) bool {
+			number, err := strconv.ParseFloat(value, 64)
+			return err == nil && number >= min
+		},
+	}
+}
+
+// ValidateMax requires numeric values to be at most max.
+func ValidateMax(max float64) Validator {
+	return Validator{
+		Description: fmt.Sprintf("must be at most %v", max),
+		Check: func(value string) bool {
+			number, err := strconv.ParseFloat(value, 64)
+			return err == nil && number <= max
+		},
+	}
+}
+
+// ValidateMinDuration requires duration values to be at least min.
+func ValidateMinDuration(min time.Duration) Validator {
+	return Validator{
+		Description: fmt.Sprintf("must be at least %v", min),
+		Check: func(value string) bool {
+			duration, err := time.ParseDuration(value)
+			return err == nil && duration >= min
+		},
+	}
+}
+
+// ValidateMaxDuration requires duration values to be at most max.
+func ValidateMaxDuration(max time.Duration) Validator {
+	return Validator{
+		Description: fmt.Sprintf("must be at most %v", max),
+		Check: func(value string) bool {
+			duration, err := time.ParseDuration(value)
+			return err == nil && duration <= max
+		},
+	}
+}
+
+// ValidateImageRef requires values to be image references, tag and digest are optional.
+func ValidateImageRef() Validator {
+	return Validator{
+		Description: "must be an image reference, e.g. quay.io/org/image:tag",
+		Check:       func(value string) bool { return GetImageName(value) != "" },
+	}
+}
+
+// ValidateImageDigest requires values to be image digests.
+func ValidateImageDigest() Validator {
+	return Validator{
+		Description: "must be an image digest, e.g. sha256:<64 hex characters>",
+		Check:       IsImageDigestValid,
+	}
+}
+
+// ValidateImageTag requires values to be image tags.
+func ValidateImageTag() Validator {
+	return Validator{
+		Description: "must be an image tag",
+		Check:       IsImageTagValid,
+	}
+}
+
+// ValidateFileExists requires values to be paths of existing files.
+func ValidateFileExists() Validator {
+	return Validator{
+		Description: "must be an existing file",
+		Check: func(value string) bool {
+			info, err := os.Stat(value)
+			return err == nil && !info.IsDir()
+		},
+	}
+}
+
+// ParameterErrors holds all problems found by ParseParameters.
+// It's a single line message, so it's readable in logs.
+type ParameterErrors []error
+
+func (e ParameterErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e ParameterErrors) Unwrap() []error {
+	return e
+}
+
+// describeParameter names the parameter flag and environment variable for error messages.
+func describeParameter(p Parameter) string {
+	if p.EnvVarName == "" {
+		return "--" + p.Name
+	}
+	return fmt.Sprintf("--%s (%s)", p.Name, p.EnvVarName)
+}
+
+// validateParameter checks the value against the parameter validators.
+// Empty values are not checked, they mean the parameter is not set. Items of arrays and maps are checked one by one.
+func validateParameter(p Parameter, value *ParameterValue) []error {
+	if len(p.Validators) == 0 {
+		return nil
+	}
+
+	items := value.Values
+	if !isMultiValueKind(p.TypeKind) {
+		items = []string{value.Value}
+	}
+
+	var errs []error
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+		// Values read from files might be secrets, don't show them.
+		shownValue := fmt.Sprintf(" '%s'", item)
+		if p.FromFile {
+			shownValue = ""
+		}
+		for _, validator := range p.Validators {
+			if !validator.Check(item) {
+				errs = append(errs, fmt.Errorf("parameter %s value%s from %s is invalid: %s",
+					describeParameter(p), shownValue, value.Source, validator.Description))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,172 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+func TestValidators(t *testing.T) {
+	g := NewWithT(t)
+
+	file := filepath.Join(t.TempDir(), "file")
+	g.Expect(os.WriteFile(file, []byte("content"), 0644)).To(Succeed())
+
+	testCases := []struct {
+		name      string
+		validator Validator
+		valid     []string
+		invalid   []string
+	}{
+		{
+			name:      "pattern",
+			validator: ValidatePattern(`^[a-z]+$`),
+			valid:     []string{"abc"},
+			invalid:   []string{"ABC", "a1"},
+		},
+		{
+			name:      "one of",
+			validator: ValidateOneOf("text", "json"),
+			valid:     []string{"text", "json"},
+			invalid:   []string{"yaml", "Text"},
+		},
+		{
+			name:      "min",
+			validator: ValidateMin(1),
+			valid:     []string{"1", "2.5"},
+			invalid:   []string{"0", "-1", "one"},
+		},
+		{
+			name:      "max",
+			validator: ValidateMax(10),
+			valid:     []string{"10", "-3"},
+			invalid:   []string{"10.5", "ten"},
+		},
+		{
+			name:      "min duration",
+			validator: ValidateMinDuration(time.Second),
+			valid:     []string{"1s", "1h"},
+			invalid:   []string{"500ms", "1"},
+		},
+		{
+			name:      "max duration",
+			validator: ValidateMaxDuration(time.Minute),
+			valid:     []string{"1m", "30s"},
+			invalid:   []string{"61s", "minute"},
+		},
+		{
+			name:      "image reference",
+			validator: ValidateImageRef(),
+			valid:     []string{"quay.io/org/app", "quay.io/org/app:v1", "registry.io:5000/app@sha256:806a5df5f70987524b87da868672ba1cec327b4d35eed01f71f2765177b7754c"},
+			invalid:   []string{"Quay.io/Org/App", "app:", "app@sha256:abc"},
+		},
+		{
+			name:      "image digest",
+			validator: ValidateImageDigest(),
+			valid:     []string{"sha256:806a5df5f70987524b87da868672ba1cec327b4d35eed01f71f2765177b7754c"},
+			invalid:   []string{"sha256:abc", "806a5df5f70987524b87da868672ba1cec327b4d35eed01f71f2765177b7754c"},
+		},
+		{
+			name:      "image tag",
+			validator: ValidateImageTag(),
+			valid:     []string{"v1.0", "latest", "_tag"},
+			invalid:   []string{"-tag", "tag:1"},
+		},
+		{
+			name:      "file exists",
+			validator: ValidateFileExists(),
+			valid:     []string{file},
+			invalid:   []string{filepath.Dir(file), file + "-missing"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(tc.validator.Description).ToNot(BeEmpty())
+			for _, value := range tc.valid {
+				g.Expect(tc.validator.Check(value)).To(BeTrue(), "expected '%s' to be valid", value)
+			}
+			for _, value := range tc.invalid {
+				g.Expect(tc.validator.Check(value)).To(BeFalse(), "expected '%s' to be invalid", value)
+			}
+		})
+	}
+}
+
+func TestParseParameters_Validation(t *testing.T) {
+	type ValidatedParams struct {
+		Output  string   `paramName:"output"`
+		Retries int      `paramName:"retries"`
+		Tags    []string `paramName:"tags"`
+		Image   string   `paramName:"image"`
+	}
+	paramsConfig := map[string]Parameter{
+		"output":  {Name: "output", TypeKind: reflect.String, EnvVarName: "KBC_TEST_OUTPUT", DefaultValue: "text", Validators: []Validator{ValidateOneOf("text", "json")}},
+		"retries": {Name: "retries", TypeKind: reflect.Int, DefaultValue: "3", Validators: []Validator{ValidateMin(0), ValidateMax(5)}},
+		"tags":    {Name: "tags", TypeKind: reflect.Array, Validators: []Validator{ValidateImageTag()}},
+		"image":   {Name: "image", TypeKind: reflect.String, Validators: []Validator{ValidateImageRef()}},
+	}
+
+	t.Run("should accept valid and empty values", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := &cobra.Command{}
+		RegisterParameters(cmd, paramsConfig)
+		g.Expect(cmd.Flags().Parse([]string{"--tags", "v1", "--tags", "latest"})).To(Succeed())
+
+		params := &ValidatedParams{}
+		g.Expect(ParseParameters(cmd, paramsConfig, params)).To(Succeed())
+		g.Expect(params.Tags).To(Equal([]string{"v1", "latest"}))
+		g.Expect(params.Image).To(BeEmpty())
+	})
+
+	t.Run("should report all violations", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := &cobra.Command{}
+		RegisterParameters(cmd, paramsConfig)
+		g.Expect(cmd.Flags().Parse([]string{"--retries", "7", "--tags", "v1", "--tags", "-bad", "--image", "Image"})).To(Succeed())
+		t.Setenv("KBC_TEST_OUTPUT", "yaml")
+
+		err := ParseParameters(cmd, paramsConfig, &ValidatedParams{})
+		g.Expect(err).To(BeAssignableToTypeOf(ParameterErrors{}))
+		g.Expect(err).To(MatchError(
+			"parameter --image value 'Image' from flag is invalid: must be an image reference, e.g. quay.io/org/image:tag; " +
+				"parameter --output (KBC_TEST_OUTPUT) value 'yaml' from env is invalid: must be one of: text, json; " +
+				"parameter --retries value '7' from flag is invalid: must be at most 5; " +
+				"parameter --tags value '-bad' from flag is invalid: must be an image tag"))
+	})
+
+	t.Run("should report missing and invalid parameters together", func(t *testing.T) {
+		g := NewWithT(t)
+		config := map[string]Parameter{
+			"output": paramsConfig["output"],
+			"image":  {Name: "image", TypeKind: reflect.String, Required: true},
+		}
+		cmd := &cobra.Command{}
+		RegisterParameters(cmd, config)
+		g.Expect(cmd.Flags().Parse([]string{"--output", "xml"})).To(Succeed())
+
+		err := ParseParameters(cmd, config, &ValidatedParams{})
+		g.Expect(err).To(MatchError(ContainSubstring("required parameter 'image' is not set")))
+		g.Expect(err).To(MatchError(ContainSubstring("value 'xml' from flag is invalid")))
+	})
+
+	t.Run("should not show values read from files", func(t *testing.T) {
+		g := NewWithT(t)
+		secretFile := filepath.Join(t.TempDir(), "token")
+		g.Expect(os.WriteFile(secretFile, []byte("s3cret"), 0600)).To(Succeed())
+		config := map[string]Parameter{
+			"image": {Name: "image", TypeKind: reflect.String, FromFile: true, Validators: []Validator{ValidatePattern(`^[0-9]+$`)}},
+		}
+		cmd := &cobra.Command{}
+		RegisterParameters(cmd, config)
+		g.Expect(cmd.Flags().Parse([]string{"--image", "@" + secretFile})).To(Succeed())
+
+		err := ParseParameters(cmd, config, &ValidatedParams{})
+		g.Expect(err).To(MatchError("parameter --image value from flag is invalid: must match ^[0-9]+$"))
+	})
+}
@@ -1,112 +1,328 @@
 package common
 
 import (
-	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
 )
 
 type Parameter struct {
-	Name         string
-	TypeKind     reflect.Kind
+	Name string
+	// TypeKind is one of reflect.String, reflect.Int, reflect.Bool, reflect.Float64, reflect.Int64 with Duration,
+	// reflect.Array (string array) or reflect.Map (string to string map given as repeated key=value pairs).
+	TypeKind reflect.Kind
+	// Duration makes reflect.Int64 parameter a time.Duration, given like "90s" or "1h30m".
+	// time.Duration has no reflect kind of its own, plain int64 parameters are not supported.
+	Duration     bool
 	ShortName    string
 	EnvVarName   string
 	DefaultValue string // makes no sense if Required is true
 	Usage        string
 	Required     bool
+	// FromFile allows to give the value as @path, then the content of the file is used.
+	// A value starting with @ is given as @@value. For arrays and maps, it applies to each item.
+	FromFile bool
+	// Validators are checked by ParseParameters for non-empty values, for arrays and maps on each item.
+	Validators []Validator
+	// Aliases are former flag names of the parameter, still accepted, but deprecated. They are hidden in help.
+	Aliases []string
+	// EnvVarAliases are former environment variable names of the parameter, still accepted, but deprecated.
+	EnvVarAliases []string
+	// AliasesRemovalVersion is the version in which the aliases are going to be removed, shown in deprecation warnings.
+	AliasesRemovalVersion string
+}
+
+// isMultiValueKind returns true for parameters given as multiple values.
+func isMultiValueKind(kind reflect.Kind) bool {
+	return kind == reflect.Array || kind == reflect.Slice || kind == reflect.Map
 }
 
 // RegisterParameters configures Cobra CLI parameters based on given Parameters data.
-func RegisterParameters(cmd *cobra.Command, paramsConfig map[string]Parameter) {
-	getMessageInvalidParameterDefaultValue := func(p Parameter) string {
-		return fmt.Sprintf("parameter '%s' has invalid default value '%v'", p.Name, p.DefaultValue)
-	}
-	var err error
+// The groups are constraints on several parameters of the command, checked by ParseParameters, see ParameterGroup.
+func RegisterParameters(cmd *cobra.Command, paramsConfig map[string]Parameter, groups ...ParameterGroup) {
+	// Fail early on invalid parameter groups
+	validateParameterGroups(paramsConfig, groups)
 
 	for pName, p := range paramsConfig {
 		if pName != p.Name {
 			panic(fmt.Sprintf("parameter name '%s' and tag '%s' must be equal", p.Name, pName))
 		}
 
-		switch p.TypeKind {
-
-		case reflect.String:
-			if p.ShortName != "" {
-				cmd.Flags().StringP(p.Name, p.ShortName, p.DefaultValue, p.Usage)
-			} else {
-				cmd.Flags().String(p.Name, p.DefaultValue, p.Usage)
-			}
-
-		case reflect.Int:
-			var defaultValue int
-			if p.DefaultValue != "" {
-				defaultValue, err = strconv.Atoi(p.DefaultValue)
-				if err != nil {
-					panic(getMessageInvalidParameterDefaultValue(p))
+		for _, name := range append([]string{p.Name}, p.Aliases...) {
+			registerParameterFlag(cmd, p, name)
+			if name != p.Name {
+				if err := cmd.Flags().MarkHidden(name); err != nil {
+					panic(err)
 				}
 			}
+		}
 
-			if p.ShortName != "" {
-				cmd.Flags().IntP(p.Name, p.ShortName, defaultValue, p.Usage)
-			} else {
-				cmd.Flags().Int(p.Name, defaultValue, p.Usage)
-			}
+		// Required parameters are not marked required in Cobra, because their values may come also from
+		// environment variables or the config file. ParseParameters checks them.
+	}
 
-		case reflect.Bool:
-			var defaultValue bool
-			if p.DefaultValue != "" {
-				defaultValue, err = strconv.ParseBool(p.DefaultValue)
-				if err != nil {
-					panic(getMessageInvalidParameterDefaultValue(p))
-				}
-			}
+	registeredParameters[cmd] = paramsConfig
+	registeredParameterGroups[cmd] = groups
+}
 
-			if p.ShortName != "" {
-				cmd.Flags().BoolP(p.Name, p.ShortName, defaultValue, p.Usage)
-			} else {
-				cmd.Flags().Bool(p.Name, defaultValue, p.Usage)
+// registerParameterFlag adds the flag of the parameter under the given name, which is the parameter name or its alias.
+// Aliases have no short names.
+func registerParameterFlag(cmd *cobra.Command, p Parameter, name string) {
+	getMessageInvalidParameterDefaultValue := func(p Parameter) string {
+		return fmt.Sprintf("parameter '%s' has invalid default value '%v'", p.Name, p.DefaultValue)
+	}
+	var err error
+
+	shortName, usage := p.ShortName, p.Usage
+	if name != p.Name {
+		shortName = ""
+		usage = fmt.Sprintf("Deprecated, use --%s instead", p.Name)
+	}
+
+	switch p.TypeKind {
+
+	case reflect.String:
+		if shortName != "" {
+			cmd.Flags().StringP(name, shortName, p.DefaultValue, usage)
+		} else {
+			cmd.Flags().String(name, p.DefaultValue, usage)
+		}
+
+	case reflect.Int:
+		var defaultValue int
+		if p.DefaultValue != "" {
+			defaultValue, err = strconv.Atoi(p.DefaultValue)
+			if err != nil {
+				panic(getMessageInvalidParameterDefaultValue(p))
 			}
+		}
 
-		case reflect.Array, reflect.Slice:
-			recordArrayParamForCommand(cmd, "--"+p.Name)
-			// Imply string array
-			var defaultValue []string = nil
-			if p.DefaultValue != "" {
-				defaultValue = strings.Fields(p.DefaultValue)
+		if shortName != "" {
+			cmd.Flags().IntP(name, shortName, defaultValue, usage)
+		} else {
+			cmd.Flags().Int(name, defaultValue, usage)
+		}
+
+	case reflect.Bool:
+		var defaultValue bool
+		if p.DefaultValue != "" {
+			defaultValue, err = strconv.ParseBool(p.DefaultValue)
+			if err != nil {
+				panic(getMessageInvalidParameterDefaultValue(p))
 			}
-			if p.ShortName != "" {
-				cmd.Flags().StringArrayP(p.Name, p.ShortName, defaultValue, p.Usage)
-				recordArrayParamForCommand(cmd, "-"+p.ShortName)
-			} else {
-				cmd.Flags().StringArray(p.Name, defaultValue, p.Usage)
+		}
+
+		if shortName != "" {
+			cmd.Flags().BoolP(name, shortName, defaultValue, usage)
+		} else {
+			cmd.Flags().Bool(name, defaultValue, usage)
+		}
+
+	case reflect.Float64:
+		var defaultValue float64
+		if p.DefaultValue != "" {
+			defaultValue, err = strconv.ParseFloat(p.DefaultValue, 64)
+			if err != nil {
+				panic(getMessageInvalidParameterDefaultValue(p))
 			}
+		}
+
+		if shortName != "" {
+			cmd.Flags().Float64P(name, shortName, defaultValue, usage)
+		} else {
+			cmd.Flags().Float64(name, defaultValue, usage)
+		}
 
-		default:
+	case reflect.Int64:
+		if !p.Duration {
 			panic("RegisterParameters: unknown parameter type")
 		}
+		var defaultValue time.Duration
+		if p.DefaultValue != "" {
+			defaultValue, err = time.ParseDuration(p.DefaultValue)
+			if err != nil {
+				panic(getMessageInvalidParameterDefaultValue(p))
+			}
+		}
 
-		if p.Required {
-			cmd.MarkFlagRequired(p.Name)
+		if shortName != "" {
+			cmd.Flags().DurationP(name, shortName, defaultValue, usage)
+		} else {
+			cmd.Flags().Duration(name, defaultValue, usage)
 		}
+
+	case reflect.Array, reflect.Slice, reflect.Map:
+		recordArrayParamForCommand(cmd, "--"+name)
+		// Imply string array, maps are given as key=value items
+		var defaultValue []string = nil
+		if p.DefaultValue != "" {
+			defaultValue = strings.Fields(p.DefaultValue)
+		}
+		if p.TypeKind == reflect.Map {
+			if _, err := parseMapItems(defaultValue); err != nil {
+				panic(getMessageInvalidParameterDefaultValue(p))
+			}
+		}
+		if shortName != "" {
+			cmd.Flags().StringArrayP(name, shortName, defaultValue, usage)
+			recordArrayParamForCommand(cmd, "-"+shortName)
+		} else {
+			cmd.Flags().StringArray(name, defaultValue, usage)
+		}
+
+	default:
+		panic("RegisterParameters: unknown parameter type")
 	}
 }
 
-// ParseParameters populates parameters structure with provided values based on paramters configuration
-func ParseParameters(cmd *cobra.Command, paramsConfig map[string]Parameter, params interface{}) error {
-	getMessageRequiredParameterMissing := func(p Parameter) string {
-		return fmt.Sprintf("required parameter '%s' is not set", p.Name)
+// ParameterSource tells where the value of a parameter comes from.
+type ParameterSource string
+
+const (
+	ParameterSourceFlag    ParameterSource = "flag"
+	ParameterSourceEnv     ParameterSource = "env"
+	ParameterSourceConfig  ParameterSource = "config"
+	ParameterSourceDefault ParameterSource = "default"
+)
+
+// ParameterValue is the effective value of a parameter before conversion to the parameter type.
+type ParameterValue struct {
+	// Value is set for single value parameters.
+	Value string
+	// Values is set for array parameters.
+	Values []string
+	Source ParameterSource
+	// Alias is the deprecated flag or environment variable name the value was given by, empty if none.
+	Alias string
+}
+
+// ResolveParameter looks up the parameter value in the command line, then in its environment variable,
+// then in the configuration file, see SetConfigFile, and falls back to the default value.
+// Values given as @path are read from files, if the parameter allows it, see Parameter.FromFile.
+func ResolveParameter(cmd *cobra.Command, p Parameter) (*ParameterValue, error) {
+	value, err := lookupParameterValue(cmd, p)
+	if err != nil || !p.FromFile {
+		return value, err
+	}
+
+	if value.Value, err = readParameterFile(p, value.Value); err != nil {
+		return nil, err
+	}
+	for i, item := range value.Values {
+		if value.Values[i], err = readParameterFile(p, item); err != nil {
+			return nil, err
+		}
 	}
+	return value, nil
+}
+
+// readParameterFile returns content of the file if the value is @path, the value otherwise.
+func readParameterFile(p Parameter, value string) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+	if strings.HasPrefix(value, "@@") {
+		return value[1:], nil
+	}
+	path := value[1:]
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read value of parameter '%s' from file: %w", p.Name, err)
+	}
+	content := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(content, "\r"), nil
+}
+
+func lookupParameterValue(cmd *cobra.Command, p Parameter) (*ParameterValue, error) {
+	isArray := isMultiValueKind(p.TypeKind)
+	flag := cmd.Flags().Lookup(p.Name)
 
+	// The current name takes precedence over aliases
+	for _, name := range append([]string{p.Name}, p.Aliases...) {
+		if !cmd.Flags().Changed(name) {
+			continue
+		}
+		value := &ParameterValue{Source: ParameterSourceFlag}
+		if name != p.Name {
+			value.Alias = name
+		}
+		if isArray {
+			val, err := cmd.Flags().GetStringArray(name)
+			value.Values = val
+			return value, err
+		}
+		value.Value = cmd.Flags().Lookup(name).Value.String()
+		return value, nil
+	}
+
+	for _, name := range append([]string{p.EnvVarName}, p.EnvVarAliases...) {
+		if name == "" {
+			continue
+		}
+		val := os.Getenv(name)
+		if val == "" {
+			continue
+		}
+		value := &ParameterValue{Value: val, Source: ParameterSourceEnv}
+		if name != p.EnvVarName {
+			value.Alias = name
+		}
+		if isArray {
+			values, err := splitArrayValue(val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of parameter '%s' from %s environment variable: %w", p.Name, name, err)
+			}
+			value.Value = ""
+			value.Values = values
+		}
+		return value, nil
+	}
+
+	if value, err := configFile.lookup(cmd, p); value != nil || err != nil {
+		return value, err
+	}
+
+	// The cli parameter was not provided nor env var set
+	if p.Required {
+		return nil, fmt.Errorf("required parameter '%s' is not set", p.Name)
+	}
+	// Fall back to default value
+	value := &ParameterValue{Value: p.DefaultValue, Source: ParameterSourceDefault}
+	if isArray {
+		value.Values = strings.Fields(p.DefaultValue)
+		if flag != nil {
+			val, err := cmd.Flags().GetStringArray(p.Name)
+			if err != nil {
+				return nil, err
+			}
+			value.Values = val
+		}
+	} else if flag != nil && p.TypeKind != reflect.String {
+		value.Value = flag.Value.String()
+	}
+	return value, nil
+}
+
+// ParseParameters populates parameters structure with provided values based on paramters configuration.
+// All missing or invalid parameters and violated parameter groups are reported in the returned error.
+func ParseParameters(cmd *cobra.Command, paramsConfig map[string]Parameter, params interface{}) error {
 	paramsStruct := reflect.ValueOf(params).Elem()
 	paramsStructType := paramsStruct.Type()
 
 	// Iterate over parameters in the top loop to avoid missing a required parameter
-	for tag, paramData := range paramsConfig {
+	var errs []error
+	sources := map[string]ParameterSource{}
+	for _, tag := range slices.Sorted(maps.Keys(paramsConfig)) {
+		paramData := paramsConfig[tag]
 		fieldFound := false
 		for i := 0; i < paramsStruct.NumField(); i++ {
 			field := paramsStructType.Field(i)
@@ -118,129 +334,25 @@ func ParseParameters(cmd *cobra.Command, paramsConfig map[string]Parameter, para
 			if fieldTag == tag {
 				fieldValue := paramsStruct.Field(i)
 				if fieldValue.CanSet() {
-					paramProvided := cmd.Flags().Changed(paramData.Name)
-
-					switch fieldValue.Kind() {
-
-					case reflect.String:
-						if paramProvided {
-							val, err := cmd.Flags().GetString(paramData.Name)
-							if err != nil {
-								return err
-							}
-							fieldValue.SetString(val)
-							break
-						}
-						if paramData.EnvVarName != "" {
-							val := os.Getenv(paramData.EnvVarName)
-							if val != "" {
-								fieldValue.SetString(val)
-								break
-							}
-						}
-						// The cli parameter was not provided nor env var set
-						if paramData.Required {
-							return errors.New(getMessageRequiredParameterMissing(paramData))
-						}
-						// Fall back to default value
-						fieldValue.SetString(paramData.DefaultValue)
-
-					case reflect.Int:
-						if paramProvided {
-							val, err := cmd.Flags().GetInt(paramData.Name)
-							if err != nil {
-								return err
-							}
-							fieldValue.SetInt(int64(val))
-							break
-						}
-						if paramData.EnvVarName != "" {
-							valStr := os.Getenv(paramData.EnvVarName)
-							if valStr != "" {
-								val, err := strconv.ParseInt(valStr, 10, 64)
-								if err != nil {
-									return err
-								}
-								fieldValue.SetInt(val)
-								break
-							}
-						}
-						// The cli parameter was not provided nor env var set
-						if paramData.Required {
-							return errors.New(getMessageRequiredParameterMissing(paramData))
-						}
-						// Fall back to default value
-						val, err := cmd.Flags().GetInt(paramData.Name)
-						if err != nil {
-							return err
-						}
-						fieldValue.SetInt(int64(val))
-
-					case reflect.Bool:
-						if paramProvided {
-							val, err := cmd.Flags().GetBool(paramData.Name)
-							if err != nil {
-								return err
-							}
-							fieldValue.SetBool(val)
-							break
-						}
-						if paramData.EnvVarName != "" {
-							valStr := os.Getenv(paramData.EnvVarName)
-							if valStr != "" {
-								val, err := strconv.ParseBool(valStr)
-								if err != nil {
-									return err
-								}
-								fieldValue.SetBool(val)
-								break
-							}
-						}
-						// The cli parameter was not provided nor env var set
-						if paramData.Required {
-							return errors.New(getMessageRequiredParameterMissing(paramData))
-						}
-						// Fall back to default value
-						val, err := cmd.Flags().GetBool(paramData.Name)
-						if err != nil {
-							return err
-						}
-						fieldValue.SetBool(val)
-
-					case reflect.Array, reflect.Slice:
-						// Imply string array
-						if paramProvided {
-							val, err := cmd.Flags().GetStringArray(paramData.Name)
-							if err != nil {
-								return err
-							}
-							fieldValue.Set(reflect.ValueOf(val))
-							break
-						}
-						if paramData.EnvVarName != "" {
-							valStr := os.Getenv(paramData.EnvVarName)
-							if valStr != "" {
-								val := strings.Fields(valStr)
-								fieldValue.Set(reflect.ValueOf(val))
-								break
-							}
-						}
-						// The cli parameter was not provided nor env var set
-						if paramData.Required {
-							return errors.New(getMessageRequiredParameterMissing(paramData))
-						}
-						// Fall back to default value
-						val, err := cmd.Flags().GetStringArray(paramData.Name)
-						if err != nil {
-							return err
-						}
-						fieldValue.Set(reflect.ValueOf(val))
-
-					default:
-						panic(fmt.Sprintf("not supported parameter type '%v' for '%s' parameter", fieldValue.Kind(), paramData.Name))
+					if !isSupportedParameterField(fieldValue.Type()) {
+						panic(fmt.Sprintf("not supported parameter type '%v' for '%s' parameter", fieldValue.Type(), paramData.Name))
 					}
 
 					fieldFound = true
+					value, err := ResolveParameter(cmd, paramData)
+					if err != nil {
+						errs = append(errs, err)
+						break
+					}
+					if value.Alias != "" {
+						l.Logger.Warn(deprecatedAliasMessage(paramData, value))
+					}
+					sources[tag] = value.Source
+					if err := setParameterField(fieldValue, paramData, value); err != nil {
+						errs = append(errs, err)
+						break
+					}
+					errs = append(errs, validateParameter(paramData, value)...)
 					break
 				} else {
 					panic(fmt.Sprintf("cannot set value for '%s' field", field.Name))
@@ -251,5 +363,133 @@ func ParseParameters(cmd *cobra.Command, paramsConfig map[string]Parameter, para
 			panic(fmt.Sprintf("field with tag '%s' not found in '%s' struct", tag, paramsStructType.Name()))
 		}
 	}
+	errs = append(errs, checkParameterGroups(registeredParameterGroups[cmd], paramsConfig, sources)...)
+	if len(errs) != 0 {
+		return ParameterErrors(errs)
+	}
+	return nil
+}
+
+// deprecatedAliasMessage tells that the parameter was given by its deprecated name and what to use instead.
+func deprecatedAliasMessage(p Parameter, value *ParameterValue) string {
+	var message string
+	if value.Source == ParameterSourceEnv {
+		replacement := p.EnvVarName + " environment variable"
+		if p.EnvVarName == "" {
+			replacement = "--" + p.Name + " flag"
+		}
+		message = fmt.Sprintf("%s environment variable is deprecated, use %s instead", value.Alias, replacement)
+	} else {
+		message = fmt.Sprintf("--%s flag is deprecated, use --%s instead", value.Alias, p.Name)
+	}
+	if p.AliasesRemovalVersion != "" {
+		message += fmt.Sprintf(", it will be removed in version %s", p.AliasesRemovalVersion)
+	}
+	return message
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func isSupportedParameterField(fieldType reflect.Type) bool {
+	switch fieldType.Kind() {
+	case reflect.String, reflect.Int, reflect.Bool, reflect.Float64, reflect.Array, reflect.Slice:
+		return true
+	case reflect.Int64:
+		return fieldType == durationType
+	case reflect.Map:
+		return fieldType.Key().Kind() == reflect.String && fieldType.Elem().Kind() == reflect.String
+	default:
+		return false
+	}
+}
+
+// parseMapItems converts key=value items into a map.
+func parseMapItems(items []string) (map[string]string, error) {
+	result := make(map[string]string, len(items))
+	for _, item := range items {
+		key, value, found := strings.Cut(item, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("'%s' is not in key=value format", item)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// setParameterField converts the parameter value to the field type.
+func setParameterField(fieldValue reflect.Value, paramData Parameter, value *ParameterValue) error {
+	getMessageInvalidParameterValue := func(err error) error {
+		if paramData.FromFile {
+			// Values read from files might be secrets, don't show them, parse errors contain the value too.
+			return fmt.Errorf("invalid value of parameter '%s' from %s: expected %s", paramData.Name, value.Source, expectedValueDescription(fieldValue.Kind()))
+		}
+		return fmt.Errorf("invalid value '%s' of parameter '%s' from %s: %w", value.Value, paramData.Name, value.Source, err)
+	}
+
+	switch fieldValue.Kind() {
+	case reflect.String:
+		fieldValue.SetString(value.Value)
+
+	case reflect.Int:
+		val, err := strconv.ParseInt(value.Value, 10, 64)
+		if err != nil {
+			return getMessageInvalidParameterValue(err)
+		}
+		fieldValue.SetInt(val)
+
+	case reflect.Bool:
+		val, err := strconv.ParseBool(value.Value)
+		if err != nil {
+			return getMessageInvalidParameterValue(err)
+		}
+		fieldValue.SetBool(val)
+
+	case reflect.Float64:
+		val, err := strconv.ParseFloat(value.Value, 64)
+		if err != nil {
+			return getMessageInvalidParameterValue(err)
+		}
+		fieldValue.SetFloat(val)
+
+	case reflect.Int64:
+		// Only time.Duration is supported, see isSupportedParameterField
+		val, err := time.ParseDuration(value.Value)
+		if err != nil {
+			return getMessageInvalidParameterValue(err)
+		}
+		fieldValue.SetInt(int64(val))
+
+	case reflect.Array, reflect.Slice:
+		// Imply string array
+		fieldValue.Set(reflect.ValueOf(value.Values))
+
+	case reflect.Map:
+		val, err := parseMapItems(value.Values)
+		if err != nil {
+			if paramData.FromFile {
+				return fmt.Errorf("invalid value of parameter '%s' from %s: expected %s", paramData.Name, value.Source, expectedValueDescription(reflect.Map))
+			}
+			return fmt.Errorf("invalid value of parameter '%s' from %s: %w", paramData.Name, value.Source, err)
+		}
+		fieldValue.Set(reflect.ValueOf(val).Convert(fieldValue.Type()))
+	}
 	return nil
 }
+
+// expectedValueDescription describes values of the parameter field kind in errors which must not show the value.
+func expectedValueDescription(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Int:
+		return "an integer"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Float64:
+		return "a number"
+	case reflect.Int64:
+		return "a duration, e.g. 90s or 1h30m"
+	case reflect.Map:
+		return "key=value items"
+	default:
+		return "a valid value"
+	}
+}
@@ -0,0 +1,113 @@
+package common
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
+)
+
+type aliasedParams struct {
+	Image string   `paramName:"image"`
+	Tags  []string `paramName:"tags"`
+}
+
+var aliasedParamsConfig = map[string]Parameter{
+	"image": {
+		Name:                  "image",
+		TypeKind:              reflect.String,
+		EnvVarName:            "KBC_TEST_IMAGE",
+		Aliases:               []string{"image-url"},
+		EnvVarAliases:         []string{"KBC_TEST_IMAGE_URL"},
+		AliasesRemovalVersion: "1.0",
+	},
+	"tags": {
+		Name:     "tags",
+		TypeKind: reflect.Array,
+		Aliases:  []string{"additional-tags"},
+	},
+}
+
+func TestParameterAliases(t *testing.T) {
+	newCommand := func(t *testing.T) *cobra.Command {
+		cmd := &cobra.Command{Use: "aliased"}
+		t.Cleanup(func() {
+			delete(registeredParameters, cmd)
+			delete(arrayParamsInCommands, cmd)
+		})
+		RegisterParameters(cmd, aliasedParamsConfig)
+		return cmd
+	}
+	captureLogs := func(t *testing.T) *bytes.Buffer {
+		var logs bytes.Buffer
+		l.Logger.SetOutput(&logs)
+		t.Cleanup(func() { l.Logger.SetOutput(os.Stderr) })
+		return &logs
+	}
+
+	t.Run("should register hidden alias flags", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newCommand(t)
+
+		g.Expect(cmd.Flags().Lookup("image").Hidden).To(BeFalse())
+		g.Expect(cmd.Flags().Lookup("image-url").Hidden).To(BeTrue())
+		g.Expect(cmd.Flags().Lookup("additional-tags").Hidden).To(BeTrue())
+		g.Expect(arrayParamsInCommands[cmd]).To(ContainElements("--tags", "--additional-tags"))
+	})
+
+	t.Run("should accept flag alias with deprecation warning", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newCommand(t)
+		logs := captureLogs(t)
+		g.Expect(cmd.Flags().Parse([]string{"--image-url", "quay.io/org/app", "--additional-tags", "v1", "--additional-tags", "v2"})).To(Succeed())
+
+		params := &aliasedParams{}
+		g.Expect(ParseParameters(cmd, aliasedParamsConfig, params)).To(Succeed())
+		g.Expect(params.Image).To(Equal("quay.io/org/app"))
+		g.Expect(params.Tags).To(Equal([]string{"v1", "v2"}))
+		g.Expect(logs.String()).To(ContainSubstring("--image-url flag is deprecated, use --image instead, it will be removed in version 1.0"))
+		g.Expect(logs.String()).To(ContainSubstring("--additional-tags flag is deprecated, use --tags instead"))
+	})
+
+	t.Run("should accept env var alias with deprecation warning", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newCommand(t)
+		logs := captureLogs(t)
+		t.Setenv("KBC_TEST_IMAGE_URL", "quay.io/org/app")
+
+		params := &aliasedParams{}
+		g.Expect(ParseParameters(cmd, aliasedParamsConfig, params)).To(Succeed())
+		g.Expect(params.Image).To(Equal("quay.io/org/app"))
+		g.Expect(logs.String()).To(ContainSubstring(
+			"KBC_TEST_IMAGE_URL environment variable is deprecated, use KBC_TEST_IMAGE environment variable instead, it will be removed in version 1.0"))
+	})
+
+	t.Run("should prefer current names over aliases", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newCommand(t)
+		logs := captureLogs(t)
+		t.Setenv("KBC_TEST_IMAGE", "quay.io/org/new")
+		t.Setenv("KBC_TEST_IMAGE_URL", "quay.io/org/old")
+		g.Expect(cmd.Flags().Parse([]string{"--tags", "new", "--additional-tags", "old"})).To(Succeed())
+
+		params := &aliasedParams{}
+		g.Expect(ParseParameters(cmd, aliasedParamsConfig, params)).To(Succeed())
+		g.Expect(params.Image).To(Equal("quay.io/org/new"))
+		g.Expect(params.Tags).To(Equal([]string{"new"}))
+		g.Expect(logs.String()).ToNot(ContainSubstring("deprecated"))
+	})
+
+	t.Run("should expand array alias", func(t *testing.T) {
+		g := NewWithT(t)
+		root := &cobra.Command{Use: "root"}
+		root.AddCommand(newCommand(t))
+
+		result := ExpandArrayParameters([]string{"aliased", "--additional-tags", "v1", "v2"})
+		g.Expect(result).To(Equal([]string{"aliased", "--additional-tags", "v1", "--additional-tags", "v2"}))
+	})
+}
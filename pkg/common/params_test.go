@@ -251,7 +251,7 @@ func TestRegisterParameters(t *testing.T) {
 		paramsConfig := map[string]Parameter{
 			"testParam": {
 				Name:     "testParam",
-				TypeKind: reflect.Float64,
+				TypeKind: reflect.Complex128,
 				Usage:    "test usage",
 			},
 		}
@@ -600,6 +600,35 @@ func TestParseParameters(t *testing.T) {
 		g.Expect(params.ArrayParam).To(Equal([]string{"item1", "item2", "item3"}))
 	})
 
+	t.Run("should parse quoted array parameter from environment variable", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cmd := &cobra.Command{}
+		cmd.Flags().StringArray("arrayParam", nil, "usage")
+
+		paramsConfig := map[string]Parameter{
+			"arrayParam": {
+				Name:       "arrayParam",
+				TypeKind:   reflect.Array,
+				EnvVarName: "ARRAY_ENV_VAR",
+			},
+		}
+
+		t.Setenv("ARRAY_ENV_VAR", `["item 1", "-2"]`)
+		params := &TestParams{}
+		g.Expect(ParseParameters(cmd, paramsConfig, params)).To(Succeed())
+		g.Expect(params.ArrayParam).To(Equal([]string{"item 1", "-2"}))
+
+		t.Setenv("ARRAY_ENV_VAR", `'item 1' item2`)
+		params = &TestParams{}
+		g.Expect(ParseParameters(cmd, paramsConfig, params)).To(Succeed())
+		g.Expect(params.ArrayParam).To(Equal([]string{"item 1", "item2"}))
+
+		t.Setenv("ARRAY_ENV_VAR", `'item 1 item2`)
+		err := ParseParameters(cmd, paramsConfig, &TestParams{})
+		g.Expect(err).To(MatchError("invalid value of parameter 'arrayParam' from ARRAY_ENV_VAR environment variable: unterminated ' quote"))
+	})
+
 	t.Run("should use default value when array parameter not provided", func(t *testing.T) {
 		g := NewWithT(t)
 
@@ -670,16 +699,15 @@ func TestParseParameters(t *testing.T) {
 		g := NewWithT(t)
 
 		type BadParams struct {
-			FloatParam float64 `paramName:"floatParam"`
+			ComplexParam complex128 `paramName:"complexParam"`
 		}
 
 		cmd := &cobra.Command{}
-		cmd.Flags().Float64("floatParam", 0.0, "usage")
 
 		paramsConfig := map[string]Parameter{
-			"floatParam": {
-				Name:     "floatParam",
-				TypeKind: reflect.Float64,
+			"complexParam": {
+				Name:     "complexParam",
+				TypeKind: reflect.Complex128,
 			},
 		}
 
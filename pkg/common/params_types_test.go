@@ -0,0 +1,213 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+func TestParameterTypes(t *testing.T) {
+	type TypesTestParams struct {
+		Timeout time.Duration     `paramName:"timeout"`
+		Ratio   float64           `paramName:"ratio"`
+		Labels  map[string]string `paramName:"label"`
+		Secret  string            `paramName:"secret"`
+		Files   []string          `paramName:"file"`
+	}
+	paramsConfig := map[string]Parameter{
+		"timeout": {Name: "timeout", ShortName: "t", TypeKind: reflect.Int64, Duration: true, EnvVarName: "KBC_TEST_TIMEOUT", DefaultValue: "1m30s"},
+		"ratio":   {Name: "ratio", TypeKind: reflect.Float64, EnvVarName: "KBC_TEST_RATIO", DefaultValue: "0.5"},
+		"label":   {Name: "label", ShortName: "l", TypeKind: reflect.Map, EnvVarName: "KBC_TEST_LABELS", DefaultValue: "a=1 b=2"},
+		"secret":  {Name: "secret", TypeKind: reflect.String, EnvVarName: "KBC_TEST_SECRET", FromFile: true},
+		"file":    {Name: "file", TypeKind: reflect.Array, FromFile: true},
+	}
+	newCommand := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		RegisterParameters(cmd, paramsConfig)
+		return cmd
+	}
+
+	t.Run("should register parameters", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newCommand()
+
+		g.Expect(cmd.Flags().Lookup("timeout").DefValue).To(Equal("1m30s"))
+		g.Expect(cmd.Flags().Lookup("timeout").Shorthand).To(Equal("t"))
+		g.Expect(cmd.Flags().Lookup("ratio").DefValue).To(Equal("0.5"))
+		g.Expect(cmd.Flags().Lookup("label").DefValue).To(Equal("[a=1,b=2]"))
+	})
+
+	t.Run("should use default values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		params := &TypesTestParams{}
+		g.Expect(ParseParameters(newCommand(), paramsConfig, params)).To(Succeed())
+		g.Expect(params).To(Equal(&TypesTestParams{
+			Timeout: 90 * time.Second,
+			Ratio:   0.5,
+			Labels:  map[string]string{"a": "1", "b": "2"},
+			Files:   []string{},
+		}))
+	})
+
+	t.Run("should parse parameters from command line", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newCommand()
+		g.Expect(cmd.Flags().Parse([]string{
+			"-t", "2h",
+			"--ratio", "1.25",
+			"-l", "app=web",
+			"--label", "url=https://example.com/?a=b",
+		})).To(Succeed())
+
+		params := &TypesTestParams{}
+		g.Expect(ParseParameters(cmd, paramsConfig, params)).To(Succeed())
+		g.Expect(params.Timeout).To(Equal(2 * time.Hour))
+		g.Expect(params.Ratio).To(Equal(1.25))
+		g.Expect(params.Labels).To(Equal(map[string]string{"app": "web", "url": "https://example.com/?a=b"}))
+	})
+
+	t.Run("should parse parameters from environment variables", func(t *testing.T) {
+		g := NewWithT(t)
+		t.Setenv("KBC_TEST_TIMEOUT", "45s")
+		t.Setenv("KBC_TEST_RATIO", "3")
+		t.Setenv("KBC_TEST_LABELS", "x=1 y=")
+
+		params := &TypesTestParams{}
+		g.Expect(ParseParameters(newCommand(), paramsConfig, params)).To(Succeed())
+		g.Expect(params.Timeout).To(Equal(45 * time.Second))
+		g.Expect(params.Ratio).To(Equal(3.0))
+		g.Expect(params.Labels).To(Equal(map[string]string{"x": "1", "y": ""}))
+	})
+
+	t.Run("should parse map from config file", func(t *testing.T) {
+		g := NewWithT(t)
+		root := &cobra.Command{Use: "root"}
+		cmd := newCommand()
+		cmd.Use = "types"
+		root.AddCommand(cmd)
+		setupConfigFile(t, "config.yaml", "types:\n  label:\n    app: web\n    tier: 2\n  timeout: 10m\n")
+
+		params := &TypesTestParams{}
+		g.Expect(ParseParameters(cmd, paramsConfig, params)).To(Succeed())
+		g.Expect(params.Labels).To(Equal(map[string]string{"app": "web", "tier": "2"}))
+		g.Expect(params.Timeout).To(Equal(10 * time.Minute))
+	})
+
+	t.Run("should fail on invalid values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		t.Setenv("KBC_TEST_TIMEOUT", "10")
+		err := ParseParameters(newCommand(), paramsConfig, &TypesTestParams{})
+		g.Expect(err).To(MatchError(ContainSubstring("invalid value '10' of parameter 'timeout' from env")))
+		t.Setenv("KBC_TEST_TIMEOUT", "")
+
+		t.Setenv("KBC_TEST_RATIO", "half")
+		err = ParseParameters(newCommand(), paramsConfig, &TypesTestParams{})
+		g.Expect(err).To(MatchError(ContainSubstring("invalid value 'half' of parameter 'ratio' from env")))
+		t.Setenv("KBC_TEST_RATIO", "")
+
+		cmd := newCommand()
+		g.Expect(cmd.Flags().Parse([]string{"--label", "=value"})).To(Succeed())
+		err = ParseParameters(cmd, paramsConfig, &TypesTestParams{})
+		g.Expect(err).To(MatchError("invalid value of parameter 'label' from flag: '=value' is not in key=value format"))
+	})
+
+	t.Run("should panic on invalid default values", func(t *testing.T) {
+		g := NewWithT(t)
+		for _, p := range []Parameter{
+			{Name: "p", TypeKind: reflect.Int64, Duration: true, DefaultValue: "5"},
+			{Name: "p", TypeKind: reflect.Float64, DefaultValue: "x"},
+			{Name: "p", TypeKind: reflect.Map, DefaultValue: "novalue"},
+		} {
+			g.Expect(func() {
+				RegisterParameters(&cobra.Command{}, map[string]Parameter{"p": p})
+			}).To(Panic())
+		}
+	})
+
+	t.Run("should panic on int64 field which is not duration", func(t *testing.T) {
+		g := NewWithT(t)
+		type BadParams struct {
+			Size int64 `paramName:"size"`
+		}
+		g.Expect(func() {
+			ParseParameters(&cobra.Command{}, map[string]Parameter{"size": {Name: "size", TypeKind: reflect.Int64}}, &BadParams{})
+		}).To(Panic())
+	})
+
+	t.Run("should not treat int64 kind as duration", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(func() {
+			RegisterParameters(&cobra.Command{}, map[string]Parameter{"size": {Name: "size", TypeKind: reflect.Int64, DefaultValue: "90s"}})
+		}).To(PanicWith("RegisterParameters: unknown parameter type"))
+	})
+
+	t.Run("should read values from files", func(t *testing.T) {
+		g := NewWithT(t)
+		dir := t.TempDir()
+		secretPath := filepath.Join(dir, "secret")
+		g.Expect(os.WriteFile(secretPath, []byte("s3cret\n"), 0600)).To(Succeed())
+		g.Expect(os.WriteFile(filepath.Join(dir, "a"), []byte("content a"), 0600)).To(Succeed())
+
+		t.Setenv("KBC_TEST_SECRET", "@"+secretPath)
+		cmd := newCommand()
+		g.Expect(cmd.Flags().Parse([]string{"--file", "@" + filepath.Join(dir, "a"), "--file", "@@literal", "--file", "plain"})).To(Succeed())
+
+		params := &TypesTestParams{}
+		g.Expect(ParseParameters(cmd, paramsConfig, params)).To(Succeed())
+		g.Expect(params.Secret).To(Equal("s3cret"))
+		g.Expect(params.Files).To(Equal([]string{"content a", "@literal", "plain"}))
+
+		t.Setenv("KBC_TEST_SECRET", "@"+filepath.Join(dir, "missing"))
+		err := ParseParameters(newCommand(), paramsConfig, &TypesTestParams{})
+		g.Expect(err).To(MatchError(ContainSubstring("failed to read value of parameter 'secret' from file")))
+	})
+
+	t.Run("should not show values read from files in parse errors", func(t *testing.T) {
+		g := NewWithT(t)
+		type FileParams struct {
+			Retries int               `paramName:"retries"`
+			Timeout time.Duration     `paramName:"timeout"`
+			Labels  map[string]string `paramName:"label"`
+		}
+		fileParamsConfig := map[string]Parameter{
+			"retries": {Name: "retries", TypeKind: reflect.Int, EnvVarName: "KBC_TEST_FILE_RETRIES", FromFile: true},
+			"timeout": {Name: "timeout", TypeKind: reflect.Int64, Duration: true, EnvVarName: "KBC_TEST_FILE_TIMEOUT", FromFile: true},
+			"label":   {Name: "label", TypeKind: reflect.Map, EnvVarName: "KBC_TEST_FILE_LABELS", FromFile: true},
+		}
+		secretPath := filepath.Join(t.TempDir(), "secret")
+		g.Expect(os.WriteFile(secretPath, []byte("s3cret"), 0600)).To(Succeed())
+
+		for envVarName, expected := range map[string]string{
+			"KBC_TEST_FILE_RETRIES": "invalid value of parameter 'retries' from env: expected an integer",
+			"KBC_TEST_FILE_TIMEOUT": "invalid value of parameter 'timeout' from env: expected a duration, e.g. 90s or 1h30m",
+			"KBC_TEST_FILE_LABELS":  "invalid value of parameter 'label' from env: expected key=value items",
+		} {
+			t.Run(envVarName, func(t *testing.T) {
+				g := NewWithT(t)
+				t.Setenv(envVarName, "@"+secretPath)
+				cmd := &cobra.Command{}
+				RegisterParameters(cmd, fileParamsConfig)
+
+				err := ParseParameters(cmd, fileParamsConfig, &FileParams{})
+				g.Expect(err).To(MatchError(expected))
+				g.Expect(err.Error()).ToNot(ContainSubstring("s3cret"))
+			})
+		}
+	})
+
+	t.Run("should not read files for parameters without FromFile", func(t *testing.T) {
+		g := NewWithT(t)
+		t.Setenv("KBC_TEST_LABELS", "k=@/etc/hostname")
+
+		params := &TypesTestParams{}
+		g.Expect(ParseParameters(newCommand(), paramsConfig, params)).To(Succeed())
+		g.Expect(params.Labels).To(Equal(map[string]string{"k": "@/etc/hostname"}))
+	})
+}
@@ -3,7 +3,13 @@ package common
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
 
 	l "github.com/konflux-ci/konflux-build-cli/pkg/logger"
 )
@@ -11,22 +17,101 @@ import (
 type ResultsWriterInterface interface {
 	CreateResultJson(result any) (string, error)
 	WriteResultString(result, path string) error
+	WriteResults(results any) error
 }
 
 var _ ResultsWriterInterface = &ResultsWriter{}
 
-type ResultsWriter struct{}
+// ResultsOverflow tells what to do with results bigger than the maximum size.
+type ResultsOverflow string
+
+const (
+	// ResultsOverflowFail fails writing the result.
+	ResultsOverflowFail ResultsOverflow = "fail"
+	// ResultsOverflowSpill writes the result into a file in the spill directory, e.g. on a workspace,
+	// and the path of that file into the result. Other than string results fail, the path isn't a valid value of them.
+	ResultsOverflowSpill ResultsOverflow = "spill"
+)
+
+// ResultsLimits configures handling of big results.
+type ResultsLimits struct {
+	// MaxSize is the maximum size of a result in bytes, 0 means unlimited.
+	MaxSize  int
+	Overflow ResultsOverflow
+	// SpillDir is the directory to write big results into, required for ResultsOverflowSpill.
+	SpillDir string
+}
+
+// resultsLimits apply to all results writers created afterwards.
+// Results are not limited by default: Tekton limits the total size of all results of a step,
+// which depends on the Tekton configuration and on other results of the step.
+var resultsLimits = ResultsLimits{Overflow: ResultsOverflowFail}
+
+// SetResultsLimits configures handling of big results of the current CLI run.
+func SetResultsLimits(limits ResultsLimits) error {
+	switch limits.Overflow {
+	case ResultsOverflowFail:
+	case ResultsOverflowSpill:
+		if limits.SpillDir == "" {
+			return fmt.Errorf("results spill directory must be set for '%s' overflow handling", limits.Overflow)
+		}
+	default:
+		return fmt.Errorf("unknown results overflow handling '%s', expected %s or %s", limits.Overflow, ResultsOverflowFail, ResultsOverflowSpill)
+	}
+	if limits.MaxSize < 0 {
+		return fmt.Errorf("results maximum size must not be negative, got %d", limits.MaxSize)
+	}
+	resultsLimits = limits
+	return nil
+}
+
+type ResultsWriter struct {
+	Limits ResultsLimits
+
+	// Results of the command and their file paths, see NewCommandResultsWriter.
+	results     []Result
+	resultPaths map[string]string
+}
 
 func NewResultsWriter() *ResultsWriter {
-	return &ResultsWriter{}
+	return &ResultsWriter{Limits: resultsLimits}
 }
 
-// WriteResultString writes result data into file by given path
+// WriteResultString writes result data into file by given path.
+// Results bigger than the maximum size fail or are spilled into a file, see ResultsLimits.
 func (r *ResultsWriter) WriteResultString(result, path string) error {
+	return r.writeResult(result, path, filepath.Base(path), true)
+}
+
+// writeResult writes result data into file by given path.
+// Only string results can be spilled, the path of the spill file isn't a valid value of other types, e.g. arrays.
+// The spill file name starts with spillName and is unique, results of several steps may share the spill directory.
+func (r *ResultsWriter) writeResult(result, path, spillName string, spillable bool) error {
 	if path == "" {
 		return nil
 	}
 
+	if r.Limits.MaxSize > 0 && len(result) > r.Limits.MaxSize {
+		if r.Limits.Overflow != ResultsOverflowSpill {
+			return fmt.Errorf("result for '%s' has %d bytes, more than the maximum of %d bytes", path, len(result), r.Limits.MaxSize)
+		}
+		if !spillable {
+			return fmt.Errorf("result for '%s' has %d bytes, more than the maximum of %d bytes, and only string results can be spilled",
+				path, len(result), r.Limits.MaxSize)
+		}
+
+		if err := os.MkdirAll(r.Limits.SpillDir, 0755); err != nil {
+			return fmt.Errorf("failed to create results spill directory '%s': %w", r.Limits.SpillDir, err)
+		}
+		spillPath, err := writeSpillFile(r.Limits.SpillDir, spillName, result)
+		if err != nil {
+			return err
+		}
+		l.Logger.Warnf("Result for '%s' has %d bytes, more than the maximum of %d bytes, wrote it into '%s' and its path into the result",
+			path, len(result), r.Limits.MaxSize, spillPath)
+		result = spillPath
+	}
+
 	if err := os.WriteFile(path, []byte(result), 0644); err != nil {
 		return fmt.Errorf("failed to write into result file '%s': %w", path, err)
 	}
@@ -36,6 +121,28 @@ func (r *ResultsWriter) WriteResultString(result, path string) error {
 	return nil
 }
 
+// writeSpillFile writes the result into a new file in the spill directory and returns its path.
+func writeSpillFile(spillDir, spillName, result string) (string, error) {
+	file, err := os.CreateTemp(spillDir, spillName+"-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create big result file in '%s': %w", spillDir, err)
+	}
+	defer file.Close()
+	spillPath := file.Name()
+
+	// The file is read by other steps, which may run as another user
+	if err := file.Chmod(0644); err != nil {
+		return "", fmt.Errorf("failed to write big result into '%s': %w", spillPath, err)
+	}
+	if _, err := file.WriteString(result); err != nil {
+		return "", fmt.Errorf("failed to write big result into '%s': %w", spillPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write big result into '%s': %w", spillPath, err)
+	}
+	return spillPath, nil
+}
+
 // CreateResultJson converts a struct with results into JSON string.
 // Mostly used by tasks to output results into stdout.
 // Note, for Tekton results, the JSON must be escaped.
@@ -47,3 +154,140 @@ func (r *ResultsWriter) CreateResultJson(result any) (string, error) {
 
 	return string(resultJson), nil
 }
+
+// Result is a field of a results struct tagged with result:"NAME", see RegisterResults.
+type Result struct {
+	Name string
+	// Description tells what the result holds, from the description tag of the field.
+	Description string
+	// Param holds the file path to write the result into.
+	Param Parameter
+	// Type is the field type.
+	Type reflect.Type
+
+	fieldIndex int
+}
+
+// registeredResults holds results of each command, see RegisterResults.
+var registeredResults = map[*cobra.Command][]Result{}
+
+// CommandResults returns results registered for the command.
+func CommandResults(cmd *cobra.Command) []Result {
+	return registeredResults[cmd]
+}
+
+// resultsDir is the directory to write results without explicit path into, empty if none.
+var resultsDir string
+
+// SetResultsDir makes results without explicit file path written into the directory, e.g. /tekton/results.
+// The file names are the result names. Empty dir disables it.
+func SetResultsDir(dir string) {
+	resultsDir = dir
+}
+
+// ResultParamPrefix starts names of parameters holding result file paths.
+const ResultParamPrefix = "result-"
+
+// ResultParamName returns name of the parameter holding the file path of the result.
+func ResultParamName(resultName string) string {
+	return ResultParamPrefix + resultName
+}
+
+// RegisterResults declares results of the command from fields of the results struct tagged with result:"NAME".
+// The optional description:"..." tag of the field describes the result, e.g. in generated Tekton definitions.
+// For each result, it registers --result-NAME parameter and envVarPrefix+NAME environment variable,
+// e.g. KBC_MYCOMMAND_RESULT_LOCATION, with the path of the file to write the result into.
+// It must be called after RegisterParameters.
+func RegisterResults(cmd *cobra.Command, results any, envVarPrefix string) {
+	resultsType := reflect.TypeOf(results)
+	if resultsType.Kind() == reflect.Pointer {
+		resultsType = resultsType.Elem()
+	}
+	if resultsType.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("results of '%s' command must be a struct", cmd.Name()))
+	}
+
+	// Don't modify the command parameters config, it's used by ParseParameters for the command params struct.
+	params := maps.Clone(registeredParameters[cmd])
+	if params == nil {
+		params = map[string]Parameter{}
+	}
+	for i := 0; i < resultsType.NumField(); i++ {
+		field := resultsType.Field(i)
+		name := field.Tag.Get("result")
+		if name == "" {
+			continue
+		}
+		paramName := ResultParamName(name)
+		if _, exists := params[paramName]; exists {
+			panic(fmt.Sprintf("parameter '%s' of '%s' result already exists", paramName, name))
+		}
+		p := Parameter{
+			Name:       paramName,
+			EnvVarName: envVarPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_")),
+			TypeKind:   reflect.String,
+			Usage:      fmt.Sprintf("File path to write the %s result into", name),
+		}
+		registerParameterFlag(cmd, p, p.Name)
+		params[paramName] = p
+		description := field.Tag.Get("description")
+		if description == "" {
+			description = fmt.Sprintf("The %s result", name)
+		}
+		registeredResults[cmd] = append(registeredResults[cmd], Result{
+			Name:        name,
+			Description: description,
+			Param:       p,
+			Type:        field.Type,
+			fieldIndex:  i,
+		})
+	}
+	registeredParameters[cmd] = params
+}
+
+// NewCommandResultsWriter creates results writer for the command results, see RegisterResults.
+func NewCommandResultsWriter(cmd *cobra.Command) (*ResultsWriter, error) {
+	writer := NewResultsWriter()
+	writer.resultPaths = map[string]string{}
+	for _, result := range registeredResults[cmd] {
+		value, err := ResolveParameter(cmd, result.Param)
+		if err != nil {
+			return nil, err
+		}
+		path := value.Value
+		if path == "" && resultsDir != "" {
+			path = filepath.Join(resultsDir, result.Name)
+		}
+		writer.resultPaths[result.Name] = path
+	}
+	writer.results = registeredResults[cmd]
+	return writer, nil
+}
+
+// WriteResults writes each result field of the results struct into its file, if the file path is given.
+// Strings are written as is, other values as JSON. Only string results are spilled, see ResultsLimits.
+func (r *ResultsWriter) WriteResults(results any) error {
+	resultsValue := reflect.ValueOf(results)
+	if resultsValue.Kind() == reflect.Pointer {
+		resultsValue = resultsValue.Elem()
+	}
+	for _, result := range r.results {
+		path := r.resultPaths[result.Name]
+		if path == "" {
+			continue
+		}
+
+		fieldValue := resultsValue.Field(result.fieldIndex)
+		content, isString := fieldValue.Interface().(string)
+		if !isString {
+			var err error
+			if content, err = r.CreateResultJson(fieldValue.Interface()); err != nil {
+				return fmt.Errorf("failed to convert %s result into JSON: %w", result.Name, err)
+			}
+		}
+		if err := r.writeResult(content, path, result.Name, isString); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,12 +2,16 @@ package common
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
 )
 
 func TestNewResultsWriter(t *testing.T) {
@@ -197,3 +201,251 @@ func TestResultsWriter_CreateResultJson(t *testing.T) {
 		g.Expect(err).To(HaveOccurred())
 	})
 }
+
+type testResults struct {
+	Digest string            `result:"digest"`
+	Tags   []string          `result:"tags" description:"Tags of the image"`
+	Labels map[string]string `result:"image-labels"`
+	Other  string
+}
+
+func TestRegisterResults(t *testing.T) {
+	g := NewWithT(t)
+
+	paramsConfig := map[string]Parameter{
+		"image": {Name: "image", TypeKind: reflect.String},
+	}
+	cmd := &cobra.Command{Use: "test"}
+	t.Cleanup(func() {
+		delete(registeredParameters, cmd)
+		delete(registeredResults, cmd)
+	})
+	RegisterParameters(cmd, paramsConfig)
+	RegisterResults(cmd, testResults{}, "KBC_TEST_RESULT_")
+
+	g.Expect(cmd.Flags().Lookup("result-digest")).ToNot(BeNil())
+	g.Expect(cmd.Flags().Lookup("result-image-labels")).ToNot(BeNil())
+	g.Expect(cmd.Flags().Lookup("result-other")).To(BeNil())
+
+	params := CommandParameters(cmd)
+	g.Expect(params).To(HaveKey("image"))
+	g.Expect(params).To(HaveKey("result-tags"))
+	g.Expect(params["result-image-labels"].EnvVarName).To(Equal("KBC_TEST_RESULT_IMAGE_LABELS"))
+	g.Expect(paramsConfig).To(HaveLen(1), "the command parameters config must not be modified")
+
+	results := CommandResults(cmd)
+	g.Expect(results).To(HaveLen(3))
+	g.Expect(results[1].Name).To(Equal("tags"))
+	g.Expect(results[1].Type).To(Equal(reflect.TypeOf([]string{})))
+	g.Expect(results[1].Description).To(Equal("Tags of the image"))
+	g.Expect(results[0].Description).To(Equal("The digest result"))
+	g.Expect(results[1].Param.Usage).To(Equal("File path to write the tags result into"))
+
+	g.Expect(func() { RegisterResults(cmd, testResults{}, "KBC_TEST_RESULT_") }).
+		To(PanicWith("parameter 'result-digest' of 'digest' result already exists"))
+	g.Expect(func() { RegisterResults(&cobra.Command{}, "results", "KBC_TEST_RESULT_") }).
+		To(PanicWith(ContainSubstring("must be a struct")))
+}
+
+func TestResultsWriter_WriteResults(t *testing.T) {
+	newCommand := func(t *testing.T) *cobra.Command {
+		cmd := &cobra.Command{Use: "test"}
+		t.Cleanup(func() {
+			delete(registeredParameters, cmd)
+			delete(registeredResults, cmd)
+		})
+		RegisterResults(cmd, testResults{}, "KBC_TEST_RESULT_")
+		return cmd
+	}
+	results := &testResults{
+		Digest: "sha256:abc",
+		Tags:   []string{"v1", "latest"},
+		Labels: map[string]string{"app": "web"},
+		Other:  "not a result",
+	}
+
+	t.Run("should write results into given files", func(t *testing.T) {
+		g := NewWithT(t)
+		tmpDir := t.TempDir()
+		cmd := newCommand(t)
+		g.Expect(cmd.Flags().Parse([]string{"--result-digest", filepath.Join(tmpDir, "digest")})).To(Succeed())
+		t.Setenv("KBC_TEST_RESULT_TAGS", filepath.Join(tmpDir, "tags"))
+
+		writer, err := NewCommandResultsWriter(cmd)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(writer.WriteResults(results)).To(Succeed())
+
+		g.Expect(os.ReadFile(filepath.Join(tmpDir, "digest"))).To(BeEquivalentTo("sha256:abc"))
+		g.Expect(os.ReadFile(filepath.Join(tmpDir, "tags"))).To(BeEquivalentTo(`["v1","latest"]`))
+		entries, err := os.ReadDir(tmpDir)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(entries).To(HaveLen(2))
+	})
+
+	t.Run("should write results without given files into results directory", func(t *testing.T) {
+		g := NewWithT(t)
+		resultsDir := t.TempDir()
+		otherDir := t.TempDir()
+		SetResultsDir(resultsDir)
+		t.Cleanup(func() { SetResultsDir("") })
+		cmd := newCommand(t)
+		g.Expect(cmd.Flags().Parse([]string{"--result-digest", filepath.Join(otherDir, "digest")})).To(Succeed())
+
+		writer, err := NewCommandResultsWriter(cmd)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(writer.WriteResults(*results)).To(Succeed())
+
+		g.Expect(os.ReadFile(filepath.Join(otherDir, "digest"))).To(BeEquivalentTo("sha256:abc"))
+		g.Expect(os.ReadFile(filepath.Join(resultsDir, "tags"))).To(BeEquivalentTo(`["v1","latest"]`))
+		g.Expect(os.ReadFile(filepath.Join(resultsDir, "image-labels"))).To(BeEquivalentTo(`{"app":"web"}`))
+		g.Expect(filepath.Join(resultsDir, "digest")).ToNot(BeAnExistingFile())
+	})
+
+	t.Run("should error if result cannot be written", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := newCommand(t)
+		g.Expect(cmd.Flags().Parse([]string{"--result-digest", filepath.Join(t.TempDir(), "missing", "digest")})).To(Succeed())
+
+		writer, err := NewCommandResultsWriter(cmd)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(writer.WriteResults(results)).To(MatchError(ContainSubstring("failed to write into result file")))
+	})
+}
+
+func TestResultsWriter_Limits(t *testing.T) {
+	bigResult := strings.Repeat("a", 11)
+
+	t.Run("should write result of maximum size", func(t *testing.T) {
+		g := NewWithT(t)
+		path := filepath.Join(t.TempDir(), "result")
+
+		writer := &ResultsWriter{Limits: ResultsLimits{MaxSize: 11, Overflow: ResultsOverflowFail}}
+		g.Expect(writer.WriteResultString(bigResult, path)).To(Succeed())
+		g.Expect(os.ReadFile(path)).To(BeEquivalentTo(bigResult))
+	})
+
+	t.Run("should not limit size if maximum is 0", func(t *testing.T) {
+		g := NewWithT(t)
+		path := filepath.Join(t.TempDir(), "result")
+
+		writer := &ResultsWriter{Limits: ResultsLimits{MaxSize: 0, Overflow: ResultsOverflowFail}}
+		g.Expect(writer.WriteResultString(bigResult, path)).To(Succeed())
+		g.Expect(os.ReadFile(path)).To(BeEquivalentTo(bigResult))
+	})
+
+	t.Run("should fail on too big result", func(t *testing.T) {
+		g := NewWithT(t)
+		path := filepath.Join(t.TempDir(), "result")
+
+		writer := &ResultsWriter{Limits: ResultsLimits{MaxSize: 10, Overflow: ResultsOverflowFail}}
+		err := writer.WriteResultString(bigResult, path)
+		g.Expect(err).To(MatchError(fmt.Sprintf("result for '%s' has 11 bytes, more than the maximum of 10 bytes", path)))
+		g.Expect(path).ToNot(BeAnExistingFile())
+	})
+
+	t.Run("should spill too big result into file", func(t *testing.T) {
+		g := NewWithT(t)
+		path := filepath.Join(t.TempDir(), "result")
+		spillDir := t.TempDir()
+
+		writer := &ResultsWriter{Limits: ResultsLimits{MaxSize: 10, Overflow: ResultsOverflowSpill, SpillDir: spillDir}}
+		g.Expect(writer.WriteResultString(bigResult, path)).To(Succeed())
+
+		spillPath, err := os.ReadFile(path)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(filepath.Dir(string(spillPath))).To(Equal(spillDir))
+		g.Expect(filepath.Base(string(spillPath))).To(HavePrefix("result-"))
+		g.Expect(os.ReadFile(string(spillPath))).To(BeEquivalentTo(bigResult))
+	})
+
+	t.Run("should spill results of the same file name into different files", func(t *testing.T) {
+		g := NewWithT(t)
+		paths := []string{filepath.Join(t.TempDir(), "result"), filepath.Join(t.TempDir(), "result")}
+		spillDir := t.TempDir()
+
+		writer := &ResultsWriter{Limits: ResultsLimits{MaxSize: 10, Overflow: ResultsOverflowSpill, SpillDir: spillDir}}
+		g.Expect(writer.WriteResultString(bigResult, paths[0])).To(Succeed())
+		g.Expect(writer.WriteResultString(bigResult+"2", paths[1])).To(Succeed())
+
+		firstSpillPath, err := os.ReadFile(paths[0])
+		g.Expect(err).ToNot(HaveOccurred())
+		secondSpillPath, err := os.ReadFile(paths[1])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(firstSpillPath).ToNot(Equal(secondSpillPath))
+		g.Expect(os.ReadFile(string(firstSpillPath))).To(BeEquivalentTo(bigResult))
+		g.Expect(os.ReadFile(string(secondSpillPath))).To(BeEquivalentTo(bigResult + "2"))
+	})
+
+	t.Run("should create spill directory", func(t *testing.T) {
+		g := NewWithT(t)
+		path := filepath.Join(t.TempDir(), "result")
+		spillDir := filepath.Join(t.TempDir(), "spill", "results")
+
+		writer := &ResultsWriter{Limits: ResultsLimits{MaxSize: 10, Overflow: ResultsOverflowSpill, SpillDir: spillDir}}
+		g.Expect(writer.WriteResultString(bigResult, path)).To(Succeed())
+		spillPath, err := os.ReadFile(path)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(os.ReadFile(string(spillPath))).To(BeEquivalentTo(bigResult))
+	})
+
+	t.Run("should error if spill directory cannot be created", func(t *testing.T) {
+		g := NewWithT(t)
+		path := filepath.Join(t.TempDir(), "result")
+		notDir := filepath.Join(t.TempDir(), "file")
+		g.Expect(os.WriteFile(notDir, nil, 0644)).To(Succeed())
+
+		writer := &ResultsWriter{Limits: ResultsLimits{MaxSize: 10, Overflow: ResultsOverflowSpill, SpillDir: notDir}}
+		g.Expect(writer.WriteResultString(bigResult, path)).To(MatchError(ContainSubstring(fmt.Sprintf("failed to create results spill directory '%s'", notDir))))
+		g.Expect(path).ToNot(BeAnExistingFile())
+	})
+
+	t.Run("should fail on too big non-string result instead of spilling it", func(t *testing.T) {
+		g := NewWithT(t)
+		cmd := &cobra.Command{Use: "test"}
+		t.Cleanup(func() {
+			delete(registeredParameters, cmd)
+			delete(registeredResults, cmd)
+		})
+		RegisterResults(cmd, testResults{}, "KBC_TEST_RESULT_")
+		tmpDir := t.TempDir()
+		spillDir := t.TempDir()
+		g.Expect(cmd.Flags().Parse([]string{
+			"--result-digest", filepath.Join(tmpDir, "digest"),
+			"--result-tags", filepath.Join(tmpDir, "tags"),
+		})).To(Succeed())
+		writer, err := NewCommandResultsWriter(cmd)
+		g.Expect(err).ToNot(HaveOccurred())
+		writer.Limits = ResultsLimits{MaxSize: 10, Overflow: ResultsOverflowSpill, SpillDir: spillDir}
+
+		err = writer.WriteResults(testResults{Digest: "sha256:abcdef", Tags: []string{"v1", "latest"}})
+		g.Expect(err).To(MatchError(ContainSubstring("only string results can be spilled")))
+		digestSpillPath, err := os.ReadFile(filepath.Join(tmpDir, "digest"))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(filepath.Base(string(digestSpillPath))).To(HavePrefix("digest-"))
+		g.Expect(filepath.Join(tmpDir, "tags")).ToNot(BeAnExistingFile())
+		g.Expect(filepath.Glob(filepath.Join(spillDir, "tags-*"))).To(BeEmpty())
+	})
+
+	t.Run("should apply configured limits to new writers", func(t *testing.T) {
+		g := NewWithT(t)
+		t.Cleanup(func() { resultsLimits = ResultsLimits{Overflow: ResultsOverflowFail} })
+
+		g.Expect(NewResultsWriter().Limits).To(Equal(ResultsLimits{Overflow: ResultsOverflowFail}))
+
+		limits := ResultsLimits{MaxSize: 100, Overflow: ResultsOverflowSpill, SpillDir: "/workspace"}
+		g.Expect(SetResultsLimits(limits)).To(Succeed())
+		g.Expect(NewResultsWriter().Limits).To(Equal(limits))
+	})
+
+	t.Run("should reject invalid limits", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(SetResultsLimits(ResultsLimits{Overflow: ResultsOverflowSpill})).
+			To(MatchError("results spill directory must be set for 'spill' overflow handling"))
+		g.Expect(SetResultsLimits(ResultsLimits{Overflow: "truncate"})).
+			To(MatchError("unknown results overflow handling 'truncate', expected fail or spill"))
+		g.Expect(SetResultsLimits(ResultsLimits{MaxSize: -1, Overflow: ResultsOverflowFail})).
+			To(MatchError("results maximum size must not be negative, got -1"))
+		g.Expect(resultsLimits).To(Equal(ResultsLimits{Overflow: ResultsOverflowFail}))
+	})
+}
@@ -8,6 +8,11 @@ import (
 
 var Logger = logrus.New()
 
+func init() {
+	// Make sure no credentials leak into logs, regardless of the logger configuration.
+	Logger.AddHook(&redactionHook{})
+}
+
 func InitLogger(logLevel string) error {
 	Logger.SetOutput(os.Stderr)
 
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RedactedValue replaces sensitive data in logs.
+const RedactedValue = "***"
+
+var (
+	secretsMutex sync.RWMutex
+	secrets      []string
+)
+
+// sensitivePatterns match well-known ways of passing credentials.
+// The first group of each pattern is kept, the second one is masked.
+var sensitivePatterns = []*regexp.Regexp{
+	// CLI options, e.g. --creds user:pass, --dest-creds=user:pass, --password secret
+	regexp.MustCompile(`(?i)(--(?:src-|dest-)?(?:creds|password|registry-token)(?:=|\s+))(\S+)`),
+	// HTTP authorization headers and bearer tokens
+	regexp.MustCompile(`(?i)(\bbearer\s+)([A-Za-z0-9\-._~+/]+=*)`),
+	regexp.MustCompile(`(?i)(\bauthorization:\s*basic\s+)([A-Za-z0-9+/]+=*)`),
+	// key=value pairs, e.g. password=secret or db_password=secret
+	regexp.MustCompile(`(?i)(\b\w*(?:password|passwd|secret|token)=)([^\s&"']+)`),
+}
+
+// AddSecret registers a value that must never appear in logs.
+// Every occurrence of the value is replaced with RedactedValue.
+func AddSecret(secret string) {
+	// Too short values would mask unrelated parts of messages.
+	if len(secret) < 3 {
+		return
+	}
+
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	if !slices.Contains(secrets, secret) {
+		secrets = append(secrets, secret)
+	}
+}
+
+// RemoveSecret unregisters a value previously added with AddSecret.
+func RemoveSecret(secret string) {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	secrets = slices.DeleteFunc(secrets, func(s string) bool { return s == secret })
+}
+
+// Redact masks registered secrets and well-known credential patterns in the given text.
+func Redact(text string) string {
+	secretsMutex.RLock()
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, RedactedValue)
+	}
+	secretsMutex.RUnlock()
+
+	for _, pattern := range sensitivePatterns {
+		text = pattern.ReplaceAllString(text, "${1}"+RedactedValue)
+	}
+	return text
+}
+
+// redactionHook masks sensitive data in every message and string field before it is written.
+type redactionHook struct{}
+
+func (h *redactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *redactionHook) Fire(entry *logrus.Entry) error {
+	entry.Message = Redact(entry.Message)
+	for key, value := range entry.Data {
+		if s, ok := value.(string); ok {
+			entry.Data[key] = Redact(s)
+		}
+	}
+	return nil
+}
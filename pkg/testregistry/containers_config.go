@@ -0,0 +1,47 @@
+package testregistry
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ConfigureContainersTools sets up skopeo and other tools using containers/image for the rest of the test:
+// the registry is marked insecure, so plain HTTP is used, any image is trusted and credentials are stored if auth is enabled.
+// The configuration is written into a temporary directory and pointed to by environment variables,
+// so the test must not run in parallel.
+func (r *Registry) ConfigureContainersTools(t testing.TB) {
+	t.Helper()
+
+	homeDir := t.TempDir()
+	configDir := filepath.Join(homeDir, ".config", "containers")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create containers config directory: %s", err.Error())
+	}
+
+	registriesConf := fmt.Sprintf("[[registry]]\nlocation = %q\ninsecure = true\n", r.Host())
+	policy := `{"default": [{"type": "insecureAcceptAnything"}]}`
+	auth := `{"auths": {}}`
+	if r.authMode != authNone {
+		credentials := base64.StdEncoding.EncodeToString([]byte(r.username + ":" + r.password))
+		auth = fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, r.Host(), credentials)
+	}
+
+	files := map[string]string{
+		"registries.conf": registriesConf,
+		"policy.json":     policy,
+		"auth.json":       auth,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(configDir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %s", name, err.Error())
+		}
+	}
+
+	// Policy is looked up in $HOME/.config/containers/policy.json before the system wide one.
+	t.Setenv("HOME", homeDir)
+	t.Setenv("CONTAINERS_REGISTRIES_CONF", filepath.Join(configDir, "registries.conf"))
+	t.Setenv("REGISTRY_AUTH_FILE", filepath.Join(configDir, "auth.json"))
+}
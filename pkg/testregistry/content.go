@@ -0,0 +1,153 @@
+package testregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ImageConfig describes an image pushed by PushImage.
+type ImageConfig struct {
+	Labels map[string]string
+	// Platform of the image, linux/amd64 if nil.
+	Platform *Platform
+}
+
+// PushBlob stores the blob in the repository.
+func (r *Registry) PushBlob(repo, mediaType string, data []byte) Descriptor {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	digest := digestOf(data)
+	r.blobs[digest] = data
+	r.repository(repo).blobs[digest] = true
+	return Descriptor{MediaType: mediaType, Digest: digest, Size: int64(len(data))}
+}
+
+// PushManifest stores the manifest in the repository and tags it, if tag is not empty.
+// It panics if the manifest is invalid or refers to missing content, which is a bug in the test.
+func (r *Registry) PushManifest(repo, tag, mediaType string, data []byte) Descriptor {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	reference := tag
+	if reference == "" {
+		reference = digestOf(data)
+	}
+	digest, _, _, err := r.putManifest(repo, reference, mediaType, data)
+	if err != nil {
+		panic(fmt.Sprintf("failed to push manifest into %s: %s", repo, err.Error()))
+	}
+	return Descriptor{MediaType: mediaType, Digest: digest, Size: int64(len(data))}
+}
+
+// PushImage stores a single platform image with the given layers and tags it, if tag is not empty.
+// The returned descriptor has the platform of the image set, so it can be used in PushIndex.
+func (r *Registry) PushImage(repo, tag string, config ImageConfig, layers ...[]byte) Descriptor {
+	platform := config.Platform
+	if platform == nil {
+		platform = &Platform{Architecture: "amd64", OS: "linux"}
+	}
+
+	layerDescriptors := []Descriptor{}
+	diffIDs := []string{}
+	for _, layer := range layers {
+		descriptor := r.PushBlob(repo, MediaTypeImageLayer, layer)
+		layerDescriptors = append(layerDescriptors, descriptor)
+		// Layers are not compressed, so their diff IDs are the digests.
+		diffIDs = append(diffIDs, descriptor.Digest)
+	}
+
+	configData := mustMarshal(map[string]any{
+		"architecture": platform.Architecture,
+		"os":           platform.OS,
+		"variant":      platform.Variant,
+		"config":       map[string]any{"Labels": config.Labels},
+		"rootfs":       map[string]any{"type": "layers", "diff_ids": diffIDs},
+	})
+	configDescriptor := r.PushBlob(repo, MediaTypeImageConfig, configData)
+
+	descriptor := r.PushManifest(repo, tag, MediaTypeImageManifest, mustMarshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     MediaTypeImageManifest,
+		"config":        configDescriptor,
+		"layers":        layerDescriptors,
+	}))
+	descriptor.Platform = platform
+	return descriptor
+}
+
+// PushIndex stores an image index of the given manifests and tags it, if tag is not empty.
+func (r *Registry) PushIndex(repo, tag string, manifests ...Descriptor) Descriptor {
+	return r.PushManifest(repo, tag, MediaTypeImageIndex, mustMarshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     MediaTypeImageIndex,
+		"manifests":     manifests,
+	}))
+}
+
+// PushArtifact stores an artifact referring to the subject, e.g. an SBOM or a signature, with the given content as its only layer.
+func (r *Registry) PushArtifact(repo, artifactType string, subject Descriptor, annotations map[string]string, content []byte) Descriptor {
+	emptyConfig := r.PushBlob(repo, MediaTypeEmptyJSON, []byte("{}"))
+	layer := r.PushBlob(repo, "application/octet-stream", content)
+	subject.Platform = nil
+
+	descriptor := r.PushManifest(repo, "", MediaTypeImageManifest, mustMarshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     MediaTypeImageManifest,
+		"artifactType":  artifactType,
+		"config":        emptyConfig,
+		"layers":        []Descriptor{layer},
+		"subject":       subject,
+		"annotations":   annotations,
+	}))
+	descriptor.ArtifactType = artifactType
+	return descriptor
+}
+
+// Tags returns sorted tags of the repository.
+func (r *Registry) Tags(repo string) []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.repos[repo]; !ok {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(r.repos[repo].tags))
+}
+
+// ResolveTag returns the digest of the manifest the tag points to, empty if there is no such tag.
+func (r *Registry) ResolveTag(repo, tag string) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.repos[repo]; !ok {
+		return ""
+	}
+	return r.repos[repo].tags[tag]
+}
+
+// Manifest returns the manifest by tag or digest.
+func (r *Registry) Manifest(repo, reference string) ([]byte, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	m, _ := r.lookupManifest(repo, reference)
+	if m == nil {
+		return nil, false
+	}
+	return m.data, true
+}
+
+// Referrers returns descriptors of manifests referring to the given digest, filtered by artifact type if not empty.
+func (r *Registry) Referrers(repo, digest, artifactType string) []Descriptor {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.referrers(repo, digest, artifactType)
+}
+
+func mustMarshal(value any) []byte {
+	data, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
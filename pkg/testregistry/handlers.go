@@ -0,0 +1,496 @@
+package testregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	manifestPathRegex  = regexp.MustCompile(`^/v2/(.+)/manifests/([^/]+)$`)
+	uploadPathRegex    = regexp.MustCompile(`^/v2/(.+)/blobs/uploads/([^/]*)$`)
+	blobPathRegex      = regexp.MustCompile(`^/v2/(.+)/blobs/([^/]+)$`)
+	tagsPathRegex      = regexp.MustCompile(`^/v2/(.+)/tags/list$`)
+	referrersPathRegex = regexp.MustCompile(`^/v2/(.+)/referrers/([^/]+)$`)
+	digestRegex        = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+const tokenPath = "/token"
+
+// statusRecorder remembers the response status for the request log.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (s *statusRecorder) WriteHeader(statusCode int) {
+	s.statusCode = statusCode
+	s.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *Registry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+	r.handle(recorder, req)
+
+	r.mutex.Lock()
+	r.requests = append(r.requests, Request{Method: req.Method, Path: req.URL.Path, StatusCode: recorder.statusCode})
+	r.mutex.Unlock()
+}
+
+func (r *Registry) handle(w http.ResponseWriter, req *http.Request) {
+	if fault := r.takeFault(req); fault != nil {
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Seconds())))
+		}
+		writeError(w, fault.StatusCode, faultErrorCode(fault.StatusCode), "injected fault")
+		return
+	}
+
+	if req.URL.Path == tokenPath && r.authMode == authToken {
+		r.handleToken(w, req)
+		return
+	}
+	if !strings.HasPrefix(req.URL.Path, "/v2/") {
+		http.NotFound(w, req)
+		return
+	}
+	if !r.authorize(w, req) {
+		return
+	}
+
+	w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+	if req.URL.Path == "/v2/" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if m := uploadPathRegex.FindStringSubmatch(req.URL.Path); m != nil {
+		r.handleUpload(w, req, m[1], m[2])
+	} else if m := manifestPathRegex.FindStringSubmatch(req.URL.Path); m != nil {
+		r.handleManifest(w, req, m[1], m[2])
+	} else if m := blobPathRegex.FindStringSubmatch(req.URL.Path); m != nil {
+		r.handleBlob(w, req, m[1], m[2])
+	} else if m := tagsPathRegex.FindStringSubmatch(req.URL.Path); m != nil {
+		r.handleTags(w, req, m[1])
+	} else if m := referrersPathRegex.FindStringSubmatch(req.URL.Path); m != nil {
+		r.handleReferrers(w, req, m[1], m[2])
+	} else {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown endpoint")
+	}
+}
+
+func (r *Registry) takeFault(req *http.Request) *Fault {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, fault := range r.faults {
+		if fault.matches(req) && (fault.Times == 0 || fault.failed < fault.Times) {
+			fault.failed++
+			return fault
+		}
+	}
+	return nil
+}
+
+func faultErrorCode(statusCode int) string {
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return "TOOMANYREQUESTS"
+	case http.StatusUnauthorized:
+		return "UNAUTHORIZED"
+	case http.StatusForbidden:
+		return "DENIED"
+	default:
+		return "UNAVAILABLE"
+	}
+}
+
+// writeError writes error response in the format of the distribution spec.
+func writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]any{
+		"errors": []map[string]string{{"code": code, "message": message}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, mediaType string, value any) {
+	data, _ := json.Marshal(value)
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (r *Registry) authorize(w http.ResponseWriter, req *http.Request) bool {
+	switch r.authMode {
+	case authBasic:
+		if username, password, ok := req.BasicAuth(); ok && username == r.username && password == r.password {
+			return true
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="testregistry"`)
+	case authToken:
+		if req.Header.Get("Authorization") == "Bearer "+r.token {
+			return true
+		}
+		challenge := fmt.Sprintf(`Bearer realm="%s%s",service="testregistry"`, r.URL(), tokenPath)
+		if m := manifestPathRegex.FindStringSubmatch(req.URL.Path); m != nil {
+			challenge += fmt.Sprintf(`,scope="repository:%s:pull,push"`, m[1])
+		}
+		w.Header().Set("WWW-Authenticate", challenge)
+	default:
+		return true
+	}
+	writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "authentication required")
+	return false
+}
+
+func (r *Registry) handleToken(w http.ResponseWriter, req *http.Request) {
+	username, password, ok := req.BasicAuth()
+	if !ok || username != r.username || password != r.password {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid credentials")
+		return
+	}
+	writeJSON(w, "application/json", map[string]any{"token": r.token, "access_token": r.token, "expires_in": 300})
+}
+
+func (r *Registry) handleManifest(w http.ResponseWriter, req *http.Request, name, reference string) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		r.mutex.Lock()
+		m, digest := r.lookupManifest(name, reference)
+		r.mutex.Unlock()
+		if m == nil {
+			writeError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", "manifest unknown")
+			return
+		}
+		w.Header().Set("Content-Type", m.mediaType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(m.data)))
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusOK)
+		if req.Method == http.MethodGet {
+			w.Write(m.data)
+		}
+
+	case http.MethodPut:
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "MANIFEST_INVALID", err.Error())
+			return
+		}
+		r.mutex.Lock()
+		digest, subject, code, err := r.putManifest(name, reference, req.Header.Get("Content-Type"), data)
+		r.mutex.Unlock()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, code, err.Error())
+			return
+		}
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/manifests/%s", name, digest))
+		w.Header().Set("Docker-Content-Digest", digest)
+		if subject != "" {
+			w.Header().Set("OCI-Subject", subject)
+		}
+		w.WriteHeader(http.StatusCreated)
+
+	case http.MethodDelete:
+		r.mutex.Lock()
+		deleted := r.deleteManifest(name, reference)
+		r.mutex.Unlock()
+		if !deleted {
+			writeError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", "manifest unknown")
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "method not allowed")
+	}
+}
+
+// lookupManifest returns the manifest by tag or digest and its digest.
+func (r *Registry) lookupManifest(name, reference string) (*manifest, string) {
+	repo, ok := r.repos[name]
+	if !ok {
+		return nil, ""
+	}
+	digest := reference
+	if tagged, ok := repo.tags[reference]; ok {
+		digest = tagged
+	}
+	return repo.manifests[digest], digest
+}
+
+// manifestContent lists fields of image manifests and indexes the registry checks.
+type manifestContent struct {
+	MediaType string       `json:"mediaType"`
+	Config    *Descriptor  `json:"config"`
+	Layers    []Descriptor `json:"layers"`
+	Manifests []Descriptor `json:"manifests"`
+	Subject   *Descriptor  `json:"subject"`
+}
+
+// putManifest stores the manifest and returns its digest and subject digest, if any.
+// On failure, it returns the distribution spec error code.
+func (r *Registry) putManifest(name, reference, mediaType string, data []byte) (string, string, string, error) {
+	var content manifestContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return "", "", "MANIFEST_INVALID", fmt.Errorf("invalid manifest: %w", err)
+	}
+	if mediaType == "" {
+		mediaType = content.MediaType
+	}
+
+	digest := digestOf(data)
+	if digestRegex.MatchString(reference) && reference != digest {
+		return "", "", "DIGEST_INVALID", fmt.Errorf("manifest digest %s does not match %s", digest, reference)
+	}
+
+	repo := r.repository(name)
+	for _, blob := range content.Layers {
+		if !repo.blobs[blob.Digest] {
+			return "", "", "MANIFEST_BLOB_UNKNOWN", fmt.Errorf("blob %s unknown", blob.Digest)
+		}
+	}
+	if content.Config != nil && !repo.blobs[content.Config.Digest] {
+		return "", "", "MANIFEST_BLOB_UNKNOWN", fmt.Errorf("blob %s unknown", content.Config.Digest)
+	}
+	for _, child := range content.Manifests {
+		if _, ok := repo.manifests[child.Digest]; !ok {
+			return "", "", "MANIFEST_UNKNOWN", fmt.Errorf("manifest %s unknown", child.Digest)
+		}
+	}
+
+	m := &manifest{mediaType: mediaType, data: data}
+	if content.Subject != nil {
+		m.subject = content.Subject.Digest
+	}
+	repo.manifests[digest] = m
+	if !digestRegex.MatchString(reference) {
+		repo.tags[reference] = digest
+	}
+	return digest, m.subject, "", nil
+}
+
+// deleteManifest removes a tag, or the manifest with all its tags if reference is a digest.
+func (r *Registry) deleteManifest(name, reference string) bool {
+	repo, ok := r.repos[name]
+	if !ok {
+		return false
+	}
+	if _, ok := repo.tags[reference]; ok {
+		delete(repo.tags, reference)
+		return true
+	}
+	if _, ok := repo.manifests[reference]; !ok {
+		return false
+	}
+	delete(repo.manifests, reference)
+	for tag, digest := range repo.tags {
+		if digest == reference {
+			delete(repo.tags, tag)
+		}
+	}
+	return true
+}
+
+func (r *Registry) handleBlob(w http.ResponseWriter, req *http.Request, name, digest string) {
+	r.mutex.Lock()
+	var data []byte
+	found := false
+	if repo, ok := r.repos[name]; ok && repo.blobs[digest] {
+		data, found = r.blobs[digest]
+	}
+	r.mutex.Unlock()
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		if !found {
+			writeError(w, http.StatusNotFound, "BLOB_UNKNOWN", "blob unknown")
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusOK)
+		if req.Method == http.MethodGet {
+			w.Write(data)
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "method not allowed")
+	}
+}
+
+func (r *Registry) handleUpload(w http.ResponseWriter, req *http.Request, name, id string) {
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BLOB_UPLOAD_INVALID", err.Error())
+		return
+	}
+	query := req.URL.Query()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if id == "" {
+		if req.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "method not allowed")
+			return
+		}
+		if mount, from := query.Get("mount"), query.Get("from"); mount != "" {
+			if source, ok := r.repos[from]; ok && source.blobs[mount] {
+				r.repository(name).blobs[mount] = true
+				writeBlobCreated(w, name, mount)
+				return
+			}
+		}
+		if digest := query.Get("digest"); digest != "" {
+			r.completeUpload(w, name, digest, data)
+			return
+		}
+		r.uploadSeq++
+		id = fmt.Sprintf("upload-%d", r.uploadSeq)
+		r.uploads[id] = &upload{repo: name, data: data}
+		writeUploadStatus(w, http.StatusAccepted, name, id, r.uploads[id])
+		return
+	}
+
+	upload, ok := r.uploads[id]
+	if !ok || upload.repo != name {
+		writeError(w, http.StatusNotFound, "BLOB_UPLOAD_UNKNOWN", "upload unknown")
+		return
+	}
+	switch req.Method {
+	case http.MethodGet:
+		writeUploadStatus(w, http.StatusNoContent, name, id, upload)
+	case http.MethodPatch:
+		upload.data = append(upload.data, data...)
+		writeUploadStatus(w, http.StatusAccepted, name, id, upload)
+	case http.MethodPut:
+		delete(r.uploads, id)
+		r.completeUpload(w, name, query.Get("digest"), append(upload.data, data...))
+	case http.MethodDelete:
+		delete(r.uploads, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "method not allowed")
+	}
+}
+
+func (r *Registry) completeUpload(w http.ResponseWriter, name, digest string, data []byte) {
+	if actual := digestOf(data); digest != actual {
+		writeError(w, http.StatusBadRequest, "DIGEST_INVALID", fmt.Sprintf("digest %s does not match content %s", digest, actual))
+		return
+	}
+	r.blobs[digest] = data
+	r.repository(name).blobs[digest] = true
+	writeBlobCreated(w, name, digest)
+}
+
+func writeBlobCreated(w http.ResponseWriter, name, digest string) {
+	w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/%s", name, digest))
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func writeUploadStatus(w http.ResponseWriter, statusCode int, name, id string, upload *upload) {
+	w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%s", name, id))
+	w.Header().Set("Docker-Upload-UUID", id)
+	w.Header().Set("Range", fmt.Sprintf("0-%d", max(len(upload.data)-1, 0)))
+	w.WriteHeader(statusCode)
+}
+
+func (r *Registry) handleTags(w http.ResponseWriter, req *http.Request, name string) {
+	r.mutex.Lock()
+	repo, ok := r.repos[name]
+	var tags []string
+	if ok {
+		for tag := range repo.tags {
+			tags = append(tags, tag)
+		}
+	}
+	r.mutex.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "NAME_UNKNOWN", "repository name not known to registry")
+		return
+	}
+	slices.Sort(tags)
+
+	query := req.URL.Query()
+	if last := query.Get("last"); last != "" {
+		index, _ := slices.BinarySearch(tags, last)
+		if index < len(tags) && tags[index] == last {
+			index++
+		}
+		tags = tags[index:]
+	}
+	if n, err := strconv.Atoi(query.Get("n")); err == nil && n >= 0 && n < len(tags) {
+		tags = tags[:n]
+		if n > 0 {
+			w.Header().Set("Link", fmt.Sprintf(`</v2/%s/tags/list?n=%d&last=%s>; rel="next"`, name, n, tags[n-1]))
+		}
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	writeJSON(w, "application/json", map[string]any{"name": name, "tags": tags})
+}
+
+func (r *Registry) handleReferrers(w http.ResponseWriter, req *http.Request, name, digest string) {
+	if !digestRegex.MatchString(digest) {
+		writeError(w, http.StatusBadRequest, "DIGEST_INVALID", "invalid digest")
+		return
+	}
+	artifactType := req.URL.Query().Get("artifactType")
+
+	r.mutex.Lock()
+	referrers := r.referrers(name, digest, artifactType)
+	r.mutex.Unlock()
+
+	if artifactType != "" {
+		w.Header().Set("OCI-Filters-Applied", "artifactType")
+	}
+	writeJSON(w, MediaTypeImageIndex, map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     MediaTypeImageIndex,
+		"manifests":     referrers,
+	})
+}
+
+// referrers returns descriptors of manifests in the repository with the given subject.
+func (r *Registry) referrers(name, subject, artifactType string) []Descriptor {
+	referrers := []Descriptor{}
+	repo, ok := r.repos[name]
+	if !ok {
+		return referrers
+	}
+	for digest, m := range repo.manifests {
+		if m.subject != subject {
+			continue
+		}
+		var content struct {
+			ArtifactType string            `json:"artifactType"`
+			Config       *Descriptor       `json:"config"`
+			Annotations  map[string]string `json:"annotations"`
+		}
+		json.Unmarshal(m.data, &content)
+		if content.ArtifactType == "" && content.Config != nil {
+			content.ArtifactType = content.Config.MediaType
+		}
+		if artifactType != "" && content.ArtifactType != artifactType {
+			continue
+		}
+		referrers = append(referrers, Descriptor{
+			MediaType:    m.mediaType,
+			Digest:       digest,
+			Size:         int64(len(m.data)),
+			ArtifactType: content.ArtifactType,
+			Annotations:  content.Annotations,
+		})
+	}
+	slices.SortFunc(referrers, func(a, b Descriptor) int { return strings.Compare(a.Digest, b.Digest) })
+	return referrers
+}
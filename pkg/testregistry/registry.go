@@ -0,0 +1,219 @@
+// Package testregistry provides an in-process OCI distribution compatible registry for tests.
+//
+// The registry keeps all content in memory and serves it over plain HTTP on a local port,
+// so commands can be tested end to end with real tools, e.g. skopeo, see Registry.ConfigureContainersTools.
+// It supports manifests, blobs (monolithic, chunked and cross-repository mount uploads), tags listing,
+// the referrers API, basic and bearer token authentication, and injection of failures like 429 or 5xx responses.
+package testregistry
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	MediaTypeImageManifest  = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeImageIndex     = "application/vnd.oci.image.index.v1+json"
+	MediaTypeImageConfig    = "application/vnd.oci.image.config.v1+json"
+	MediaTypeImageLayer     = "application/vnd.oci.image.layer.v1.tar"
+	MediaTypeEmptyJSON      = "application/vnd.oci.empty.v1+json"
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// Descriptor describes content stored in the registry.
+type Descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Platform     *Platform         `json:"platform,omitempty"`
+}
+
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// Request is a request received by the registry.
+type Request struct {
+	Method string
+	Path   string
+	// StatusCode is the status of the response.
+	StatusCode int
+}
+
+// Fault makes the registry fail matching requests with the given status code.
+type Fault struct {
+	// Method to match, any if empty.
+	Method string
+	// PathContains is a substring of the request path to match, any path if empty.
+	PathContains string
+	// StatusCode of the response, e.g. 429 or 503.
+	StatusCode int
+	// RetryAfter, if set, is returned in the Retry-After header.
+	RetryAfter time.Duration
+	// Times is the number of matching requests to fail, all of them if zero.
+	Times int
+
+	failed int
+}
+
+func (f *Fault) matches(r *http.Request) bool {
+	return (f.Method == "" || f.Method == r.Method) && strings.Contains(r.URL.Path, f.PathContains)
+}
+
+type authMode int
+
+const (
+	authNone authMode = iota
+	authBasic
+	authToken
+)
+
+type Option func(*Registry)
+
+// WithBasicAuth makes the registry require basic authentication.
+func WithBasicAuth(username, password string) Option {
+	return func(r *Registry) {
+		r.authMode = authBasic
+		r.username = username
+		r.password = password
+	}
+}
+
+// WithTokenAuth makes the registry require a bearer token issued by its token endpoint for the given credentials,
+// as Docker Hub or Quay do.
+func WithTokenAuth(username, password string) Option {
+	return func(r *Registry) {
+		r.authMode = authToken
+		r.username = username
+		r.password = password
+	}
+}
+
+type manifest struct {
+	mediaType string
+	data      []byte
+	// subject is the digest of the manifest this one refers to, if any.
+	subject string
+}
+
+type repository struct {
+	manifests map[string]*manifest
+	tags      map[string]string
+	blobs     map[string]bool
+}
+
+func newRepository() *repository {
+	return &repository{
+		manifests: map[string]*manifest{},
+		tags:      map[string]string{},
+		blobs:     map[string]bool{},
+	}
+}
+
+type upload struct {
+	repo string
+	data []byte
+}
+
+// Registry is an in-memory OCI registry served on a local port.
+// It's safe for concurrent use.
+type Registry struct {
+	server *httptest.Server
+
+	authMode authMode
+	username string
+	password string
+	token    string
+
+	mutex     sync.Mutex
+	repos     map[string]*repository
+	blobs     map[string][]byte
+	uploads   map[string]*upload
+	uploadSeq int
+	faults    []*Fault
+	requests  []Request
+}
+
+// New starts a registry which is stopped at the end of the test.
+func New(t testing.TB, opts ...Option) *Registry {
+	r := &Registry{
+		repos:   map[string]*repository{},
+		blobs:   map[string][]byte{},
+		uploads: map[string]*upload{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.authMode == authToken {
+		r.token = fmt.Sprintf("token-%x", sha256.Sum256([]byte(r.username+":"+r.password)))
+	}
+	r.server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// Host returns host and port of the registry to be used in image references, e.g. 127.0.0.1:12345.
+func (r *Registry) Host() string {
+	return strings.TrimPrefix(r.server.URL, "http://")
+}
+
+// URL returns base URL of the registry.
+func (r *Registry) URL() string {
+	return r.server.URL
+}
+
+// Image returns reference to an image in the registry, e.g. Image("org/app:v1").
+func (r *Registry) Image(nameAndReference string) string {
+	return r.Host() + "/" + nameAndReference
+}
+
+// Close stops the registry.
+func (r *Registry) Close() {
+	r.server.Close()
+}
+
+// InjectFault makes the registry fail matching requests.
+// Faults are checked in the order they were added, before authentication.
+func (r *Registry) InjectFault(fault Fault) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.faults = append(r.faults, &fault)
+}
+
+// ClearFaults removes all injected faults.
+func (r *Registry) ClearFaults() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.faults = nil
+}
+
+// Requests returns all requests received by the registry so far.
+func (r *Registry) Requests() []Request {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Request{}, r.requests...)
+}
+
+func digestOf(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func (r *Registry) repository(name string) *repository {
+	repo, ok := r.repos[name]
+	if !ok {
+		repo = newRepository()
+		r.repos[name] = repo
+	}
+	return repo
+}
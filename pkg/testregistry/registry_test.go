@@ -0,0 +1,278 @@
+package testregistry_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/konflux-ci/konflux-build-cli/pkg/testregistry"
+)
+
+func digestOf(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func do(g *WithT, method, url string, body []byte, headers map[string]string) (*http.Response, []byte) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	g.Expect(err).ToNot(HaveOccurred())
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	g.Expect(err).ToNot(HaveOccurred())
+	return resp, data
+}
+
+func TestRegistry_PullPushedImage(t *testing.T) {
+	g := NewWithT(t)
+	registry := testregistry.New(t)
+
+	image := registry.PushImage("org/app", "v1", testregistry.ImageConfig{Labels: map[string]string{"version": "1"}}, []byte("layer"))
+
+	resp, manifest := do(g, http.MethodGet, registry.URL()+"/v2/org/app/manifests/v1", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(resp.Header.Get("Content-Type")).To(Equal(testregistry.MediaTypeImageManifest))
+	g.Expect(resp.Header.Get("Docker-Content-Digest")).To(Equal(image.Digest))
+	g.Expect(digestOf(manifest)).To(Equal(image.Digest))
+
+	var content struct {
+		Config testregistry.Descriptor   `json:"config"`
+		Layers []testregistry.Descriptor `json:"layers"`
+	}
+	g.Expect(json.Unmarshal(manifest, &content)).To(Succeed())
+	g.Expect(content.Layers).To(HaveLen(1))
+
+	resp, config := do(g, http.MethodGet, registry.URL()+"/v2/org/app/blobs/"+content.Config.Digest, nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(string(config)).To(ContainSubstring(`"Labels":{"version":"1"}`))
+
+	resp, layer := do(g, http.MethodGet, registry.URL()+"/v2/org/app/blobs/"+content.Layers[0].Digest, nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(string(layer)).To(Equal("layer"))
+
+	resp, _ = do(g, http.MethodHead, registry.URL()+"/v2/org/app/manifests/"+image.Digest, nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	resp, body := do(g, http.MethodGet, registry.URL()+"/v2/org/app/manifests/missing", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+	g.Expect(string(body)).To(ContainSubstring("MANIFEST_UNKNOWN"))
+
+	resp, _ = do(g, http.MethodGet, registry.URL()+"/v2/other/app/blobs/"+content.Layers[0].Digest, nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+}
+
+func TestRegistry_PushOverAPI(t *testing.T) {
+	g := NewWithT(t)
+	registry := testregistry.New(t)
+
+	// Chunked upload
+	resp, _ := do(g, http.MethodPost, registry.URL()+"/v2/org/app/blobs/uploads/", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+	location := resp.Header.Get("Location")
+	resp, _ = do(g, http.MethodPatch, registry.URL()+location, []byte("lay"), nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+	g.Expect(resp.Header.Get("Range")).To(Equal("0-2"))
+	layerDigest := digestOf([]byte("layer"))
+	resp, _ = do(g, http.MethodPut, registry.URL()+resp.Header.Get("Location")+"?digest="+layerDigest, []byte("er"), nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+	g.Expect(resp.Header.Get("Docker-Content-Digest")).To(Equal(layerDigest))
+
+	// Monolithic upload with wrong digest
+	resp, body := do(g, http.MethodPost, registry.URL()+"/v2/org/app/blobs/uploads/?digest="+layerDigest, []byte("{}"), nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+	g.Expect(string(body)).To(ContainSubstring("DIGEST_INVALID"))
+
+	// Monolithic upload
+	configDigest := digestOf([]byte("{}"))
+	resp, _ = do(g, http.MethodPost, registry.URL()+"/v2/org/app/blobs/uploads/?digest="+configDigest, []byte("{}"), nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"config":{"mediaType":%q,"digest":%q,"size":2},"layers":[{"mediaType":%q,"digest":%q,"size":5}]}`,
+		testregistry.MediaTypeImageManifest, testregistry.MediaTypeImageConfig, configDigest, testregistry.MediaTypeImageLayer, layerDigest))
+	resp, _ = do(g, http.MethodPut, registry.URL()+"/v2/org/app/manifests/v1", manifest, map[string]string{"Content-Type": testregistry.MediaTypeImageManifest})
+	g.Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+	g.Expect(resp.Header.Get("Docker-Content-Digest")).To(Equal(digestOf(manifest)))
+
+	g.Expect(registry.ResolveTag("org/app", "v1")).To(Equal(digestOf(manifest)))
+
+	// Cross repository mount
+	resp, _ = do(g, http.MethodPost, registry.URL()+"/v2/org/copy/blobs/uploads/?mount="+layerDigest+"&from=org/app", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+	resp, _ = do(g, http.MethodHead, registry.URL()+"/v2/org/copy/blobs/"+layerDigest, nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	// Manifest referring to missing blobs
+	resp, body = do(g, http.MethodPut, registry.URL()+"/v2/org/empty/manifests/v1", manifest, map[string]string{"Content-Type": testregistry.MediaTypeImageManifest})
+	g.Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+	g.Expect(string(body)).To(ContainSubstring("MANIFEST_BLOB_UNKNOWN"))
+}
+
+func TestRegistry_TagsAndDelete(t *testing.T) {
+	g := NewWithT(t)
+	registry := testregistry.New(t)
+
+	image := registry.PushImage("app", "v1", testregistry.ImageConfig{})
+	for _, tag := range []string{"v2", "latest"} {
+		registry.PushManifest("app", tag, testregistry.MediaTypeImageManifest, mustGetManifest(g, registry, image.Digest))
+	}
+	g.Expect(registry.Tags("app")).To(Equal([]string{"latest", "v1", "v2"}))
+
+	resp, body := do(g, http.MethodGet, registry.URL()+"/v2/app/tags/list?n=2", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(string(body)).To(MatchJSON(`{"name":"app","tags":["latest","v1"]}`))
+	g.Expect(resp.Header.Get("Link")).To(Equal(`</v2/app/tags/list?n=2&last=v1>; rel="next"`))
+
+	_, body = do(g, http.MethodGet, registry.URL()+"/v2/app/tags/list?n=2&last=v1", nil, nil)
+	g.Expect(string(body)).To(MatchJSON(`{"name":"app","tags":["v2"]}`))
+
+	resp, _ = do(g, http.MethodDelete, registry.URL()+"/v2/app/manifests/latest", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+	g.Expect(registry.Tags("app")).To(Equal([]string{"v1", "v2"}))
+
+	resp, _ = do(g, http.MethodDelete, registry.URL()+"/v2/app/manifests/"+image.Digest, nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+	g.Expect(registry.Tags("app")).To(BeEmpty())
+
+	resp, _ = do(g, http.MethodGet, registry.URL()+"/v2/unknown/tags/list", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+}
+
+func mustGetManifest(g *WithT, registry *testregistry.Registry, reference string) []byte {
+	manifest, ok := registry.Manifest("app", reference)
+	g.Expect(ok).To(BeTrue())
+	return manifest
+}
+
+func TestRegistry_Index(t *testing.T) {
+	g := NewWithT(t)
+	registry := testregistry.New(t)
+
+	amd64 := registry.PushImage("app", "", testregistry.ImageConfig{}, []byte("amd64"))
+	arm64 := registry.PushImage("app", "", testregistry.ImageConfig{Platform: &testregistry.Platform{Architecture: "arm64", OS: "linux"}}, []byte("arm64"))
+	index := registry.PushIndex("app", "v1", amd64, arm64)
+
+	resp, body := do(g, http.MethodGet, registry.URL()+"/v2/app/manifests/v1", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(resp.Header.Get("Content-Type")).To(Equal(testregistry.MediaTypeImageIndex))
+	g.Expect(digestOf(body)).To(Equal(index.Digest))
+	g.Expect(string(body)).To(ContainSubstring(`"architecture":"arm64"`))
+
+	g.Expect(func() { registry.PushIndex("other", "v1", amd64) }).To(Panic())
+}
+
+func TestRegistry_Referrers(t *testing.T) {
+	g := NewWithT(t)
+	registry := testregistry.New(t)
+
+	image := registry.PushImage("app", "v1", testregistry.ImageConfig{})
+	sbom := registry.PushArtifact("app", "application/spdx+json", image, map[string]string{"org.example": "sbom"}, []byte("{}"))
+	signature := registry.PushArtifact("app", "application/vnd.dev.cosign.artifact.sig.v1+json", image, nil, []byte("sig"))
+	g.Expect(registry.Referrers("app", image.Digest, "")).To(HaveLen(2))
+
+	resp, body := do(g, http.MethodGet, registry.URL()+"/v2/app/referrers/"+image.Digest+"?artifactType=application/spdx%2Bjson", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(resp.Header.Get("OCI-Filters-Applied")).To(Equal("artifactType"))
+	var index struct {
+		Manifests []testregistry.Descriptor `json:"manifests"`
+	}
+	g.Expect(json.Unmarshal(body, &index)).To(Succeed())
+	g.Expect(index.Manifests).To(HaveLen(1))
+	g.Expect(index.Manifests[0].Digest).To(Equal(sbom.Digest))
+	g.Expect(index.Manifests[0].Annotations).To(HaveKeyWithValue("org.example", "sbom"))
+	g.Expect(signature.Digest).ToNot(Equal(sbom.Digest))
+
+	_, body = do(g, http.MethodGet, registry.URL()+"/v2/app/referrers/"+sbom.Digest, nil, nil)
+	g.Expect(string(body)).To(ContainSubstring(`"manifests":[]`))
+}
+
+func TestRegistry_BasicAuth(t *testing.T) {
+	g := NewWithT(t)
+	registry := testregistry.New(t, testregistry.WithBasicAuth("user", "pass"))
+
+	resp, _ := do(g, http.MethodGet, registry.URL()+"/v2/", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+	g.Expect(resp.Header.Get("WWW-Authenticate")).To(Equal(`Basic realm="testregistry"`))
+
+	req, _ := http.NewRequest(http.MethodGet, registry.URL()+"/v2/", nil)
+	req.SetBasicAuth("user", "pass")
+	resp, err := http.DefaultClient.Do(req)
+	g.Expect(err).ToNot(HaveOccurred())
+	resp.Body.Close()
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+}
+
+func TestRegistry_TokenAuth(t *testing.T) {
+	g := NewWithT(t)
+	registry := testregistry.New(t, testregistry.WithTokenAuth("user", "pass"))
+	registry.PushImage("app", "v1", testregistry.ImageConfig{})
+
+	resp, _ := do(g, http.MethodGet, registry.URL()+"/v2/app/manifests/v1", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+	g.Expect(resp.Header.Get("WWW-Authenticate")).To(Equal(
+		fmt.Sprintf(`Bearer realm="%s/token",service="testregistry",scope="repository:app:pull,push"`, registry.URL())))
+
+	resp, _ = do(g, http.MethodGet, registry.URL()+"/token", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+
+	req, _ := http.NewRequest(http.MethodGet, registry.URL()+"/token?service=testregistry&scope=repository:app:pull", nil)
+	req.SetBasicAuth("user", "pass")
+	resp, err := http.DefaultClient.Do(req)
+	g.Expect(err).ToNot(HaveOccurred())
+	var token struct {
+		Token string `json:"token"`
+	}
+	g.Expect(json.NewDecoder(resp.Body).Decode(&token)).To(Succeed())
+	resp.Body.Close()
+	g.Expect(token.Token).ToNot(BeEmpty())
+
+	resp, _ = do(g, http.MethodGet, registry.URL()+"/v2/app/manifests/v1", nil, map[string]string{"Authorization": "Bearer " + token.Token})
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+}
+
+func TestRegistry_Faults(t *testing.T) {
+	g := NewWithT(t)
+	registry := testregistry.New(t)
+	registry.PushImage("app", "v1", testregistry.ImageConfig{})
+
+	registry.InjectFault(testregistry.Fault{
+		Method:       http.MethodGet,
+		PathContains: "/manifests/",
+		StatusCode:   http.StatusTooManyRequests,
+		RetryAfter:   2 * time.Second,
+		Times:        2,
+	})
+	registry.InjectFault(testregistry.Fault{PathContains: "/tags/", StatusCode: http.StatusServiceUnavailable})
+
+	for range 2 {
+		resp, body := do(g, http.MethodGet, registry.URL()+"/v2/app/manifests/v1", nil, nil)
+		g.Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+		g.Expect(resp.Header.Get("Retry-After")).To(Equal("2"))
+		g.Expect(string(body)).To(ContainSubstring("TOOMANYREQUESTS"))
+	}
+	resp, _ := do(g, http.MethodGet, registry.URL()+"/v2/app/manifests/v1", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	resp, _ = do(g, http.MethodHead, registry.URL()+"/v2/app/manifests/v1", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	for range 3 {
+		resp, _ = do(g, http.MethodGet, registry.URL()+"/v2/app/tags/list", nil, nil)
+		g.Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+	}
+	registry.ClearFaults()
+	resp, _ = do(g, http.MethodGet, registry.URL()+"/v2/app/tags/list", nil, nil)
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	requests := registry.Requests()
+	g.Expect(requests).To(HaveLen(8))
+	g.Expect(requests[0]).To(Equal(testregistry.Request{Method: http.MethodGet, Path: "/v2/app/manifests/v1", StatusCode: http.StatusTooManyRequests}))
+}